@@ -0,0 +1,379 @@
+//
+// Package bmp reads and writes Windows BMP images. It reads 1, 4 and 8 bit (paletted), 16, 24 and 32 bit
+// uncompressed images (with or without bit field masks, so 32 bit images with an alpha channel come through),
+// stored bottom up or top down. It writes 24 bit images, or 32 bit ones with an alpha channel if the image
+// isn't opaque. Importing the package registers the decoder with the image package.
+//
+// See https://en.wikipedia.org/wiki/BMP_file_format
+//
+package bmp
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"math/bits"
+)
+
+//
+// ErrFormat is returned when the data isn't a BMP we can read
+//
+var ErrFormat = errors.New("bmp: invalid format")
+
+//
+// ErrUnsupported is returned for BMPs that are valid, but use something we don't handle (eg. RLE compression)
+//
+var ErrUnsupported = errors.New("bmp: unsupported format")
+
+//
+// The compression methods we handle...
+//
+const (
+	compressionRGB            = 0
+	compressionBitFields      = 3
+	compressionAlphaBitFields = 6
+)
+
+const (
+	fileHeaderSize = 14
+	infoHeaderSize = 40
+	v4HeaderSize   = 108
+
+	// the images we'll read are limited to this many pixels (like the qoi package), so a corrupt header can't make
+	// us allocate gigabytes
+	maxPixels = 400000000
+)
+
+//
+// header is what we need from the file and DIB headers to read the pixels
+//
+type header struct {
+	width, height int
+	topDown       bool
+	bitCount      int
+	compression   uint32
+	masks         [4]uint32 // red, green, blue, alpha
+	palette       color.Palette
+	pixelOffset   int
+}
+
+func init() {
+	image.RegisterFormat("bmp", "BM????\x00\x00\x00\x00", Decode, DecodeConfig)
+}
+
+//
+// DecodeConfig returns the dimensions and colour model of a BMP without reading the pixels
+//
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	h, _, err := readHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	model := color.Model(color.NRGBAModel)
+	if h.palette != nil {
+		model = h.palette
+	}
+
+	return image.Config{ColorModel: model, Width: h.width, Height: h.height}, nil
+}
+
+//
+// Decode reads a BMP; paletted ones come back as an image.Paletted, and everything else as an image.NRGBA
+//
+func Decode(r io.Reader) (image.Image, error) {
+	h, read, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// skip to the pixels (there can be a gap after the palette)
+	if h.pixelOffset < read {
+		return nil, ErrFormat
+	}
+
+	if _, err := io.CopyN(ioutil.Discard, r, int64(h.pixelOffset-read)); err != nil {
+		return nil, err
+	}
+
+	// each row is padded to a multiple of 4 bytes
+	rowSize := ((h.width*h.bitCount + 31) / 32) * 4
+	row := make([]byte, rowSize)
+	bounds := image.Rect(0, 0, h.width, h.height)
+
+	var paletted *image.Paletted
+	var nrgba *image.NRGBA
+	if h.palette != nil {
+		paletted = image.NewPaletted(bounds, h.palette)
+	} else {
+		nrgba = image.NewNRGBA(bounds)
+	}
+
+	// (32 bit images without an alpha mask often have rubbish, or all zeros, in the 4th byte, so it's ignored)
+	hasAlpha := h.masks[3] != 0
+
+	for i := 0; i < h.height; i++ {
+		if _, err := io.ReadFull(r, row); err != nil {
+			return nil, err
+		}
+
+		y := h.height - 1 - i
+		if h.topDown {
+			y = i
+		}
+
+		for x := 0; x < h.width; x++ {
+			switch h.bitCount {
+			case 1, 2, 4, 8:
+				bit := x * h.bitCount
+				index := (row[bit/8] >> uint(8-h.bitCount-bit%8)) & byte(1<<uint(h.bitCount)-1)
+				if int(index) >= len(h.palette) {
+					index = 0
+				}
+				paletted.SetColorIndex(x, y, index)
+			case 24:
+				b, g, r := row[x*3], row[x*3+1], row[x*3+2]
+				nrgba.SetNRGBA(x, y, color.NRGBA{r, g, b, 255})
+			case 16, 32:
+				var v uint32
+				if h.bitCount == 16 {
+					v = uint32(binary.LittleEndian.Uint16(row[x*2:]))
+				} else {
+					v = binary.LittleEndian.Uint32(row[x*4:])
+				}
+
+				a := uint8(255)
+				if hasAlpha {
+					a = extractChannel(v, h.masks[3])
+				}
+
+				nrgba.SetNRGBA(x, y, color.NRGBA{
+					extractChannel(v, h.masks[0]),
+					extractChannel(v, h.masks[1]),
+					extractChannel(v, h.masks[2]),
+					a,
+				})
+			}
+		}
+	}
+
+	if paletted != nil {
+		return paletted, nil
+	}
+
+	return nrgba, nil
+}
+
+//
+// readHeader reads the file header, the DIB header and the palette; it returns how many bytes it read, so the
+// caller can skip to the pixels
+//
+func readHeader(r io.Reader) (header, int, error) {
+	h := header{}
+
+	fileHeader := make([]byte, fileHeaderSize+4)
+	if _, err := io.ReadFull(r, fileHeader); err != nil {
+		return h, 0, err
+	}
+
+	if string(fileHeader[:2]) != "BM" {
+		return h, 0, ErrFormat
+	}
+
+	h.pixelOffset = int(binary.LittleEndian.Uint32(fileHeader[10:]))
+	dibSize := int(binary.LittleEndian.Uint32(fileHeader[14:]))
+	if dibSize < 12 || dibSize > 1024 {
+		return h, 0, ErrFormat
+	}
+
+	dib := make([]byte, dibSize)
+	copy(dib, fileHeader[14:])
+	if _, err := io.ReadFull(r, dib[4:]); err != nil {
+		return h, 0, err
+	}
+
+	read := fileHeaderSize + dibSize
+	paletteEntrySize := 4
+	paletteSize := 0
+
+	if dibSize == 12 {
+		// the old OS/2 BITMAPCOREHEADER; 16 bit dimensions and 3 byte palette entries
+		h.width = int(binary.LittleEndian.Uint16(dib[4:]))
+		h.height = int(int16(binary.LittleEndian.Uint16(dib[6:])))
+		h.bitCount = int(binary.LittleEndian.Uint16(dib[10:]))
+		paletteEntrySize = 3
+	} else {
+		if dibSize < infoHeaderSize {
+			return h, 0, ErrFormat
+		}
+
+		h.width = int(int32(binary.LittleEndian.Uint32(dib[4:])))
+		h.height = int(int32(binary.LittleEndian.Uint32(dib[8:])))
+		h.bitCount = int(binary.LittleEndian.Uint16(dib[14:]))
+		h.compression = binary.LittleEndian.Uint32(dib[16:])
+		paletteSize = int(binary.LittleEndian.Uint32(dib[32:]))
+
+		// the masks follow the info header (and are part of the later, bigger, headers)
+		if h.compression == compressionBitFields || h.compression == compressionAlphaBitFields {
+			numMasks := 3
+			if h.compression == compressionAlphaBitFields {
+				numMasks = 4
+			}
+
+			masks := dib[infoHeaderSize:]
+			if dibSize == infoHeaderSize {
+				masks = make([]byte, numMasks*4)
+				if _, err := io.ReadFull(r, masks); err != nil {
+					return h, 0, err
+				}
+				read += len(masks)
+			} else if len(masks) < 16 {
+				masks = append(masks, make([]byte, 16-len(masks))...)
+			}
+
+			for i := 0; i < 4; i++ {
+				if i < numMasks || dibSize >= 56 {
+					h.masks[i] = binary.LittleEndian.Uint32(masks[i*4:])
+				}
+			}
+		}
+	}
+
+	if h.height < 0 {
+		h.height = -h.height
+		h.topDown = true
+	}
+
+	if h.width <= 0 || h.height == 0 || h.height >= maxPixels/h.width {
+		return h, 0, ErrFormat
+	}
+
+	switch {
+	case h.compression != compressionRGB && h.compression != compressionBitFields && h.compression != compressionAlphaBitFields:
+		return h, 0, ErrUnsupported
+	case h.bitCount <= 8:
+		if h.bitCount != 1 && h.bitCount != 2 && h.bitCount != 4 && h.bitCount != 8 {
+			return h, 0, ErrFormat
+		}
+
+		if paletteSize == 0 || paletteSize > 1<<uint(h.bitCount) {
+			paletteSize = 1 << uint(h.bitCount)
+		}
+
+		entries := make([]byte, paletteSize*paletteEntrySize)
+		if _, err := io.ReadFull(r, entries); err != nil {
+			return h, 0, err
+		}
+		read += len(entries)
+
+		for i := 0; i < paletteSize; i++ {
+			entry := entries[i*paletteEntrySize:]
+			h.palette = append(h.palette, color.RGBA{entry[2], entry[1], entry[0], 255})
+		}
+	case h.bitCount == 16 && h.compression == compressionRGB:
+		// 5 bits each of red, green and blue
+		h.masks = [4]uint32{0x7C00, 0x03E0, 0x001F, 0}
+	case h.bitCount == 32 && h.compression == compressionRGB:
+		h.masks = [4]uint32{0x00FF0000, 0x0000FF00, 0x000000FF, 0}
+	case h.bitCount != 16 && h.bitCount != 24 && h.bitCount != 32:
+		return h, 0, ErrFormat
+	}
+
+	return h, read, nil
+}
+
+//
+// extractChannel returns the bits of v picked out by mask, scaled to 0-255
+//
+func extractChannel(v, mask uint32) uint8 {
+	if mask == 0 {
+		return 0
+	}
+
+	shift := uint(bits.TrailingZeros32(mask))
+	size := uint(bits.OnesCount32(mask))
+	value := (v & mask) >> shift
+	max := uint32(1)<<size - 1
+
+	return uint8((value*255 + max/2) / max)
+}
+
+//
+// Encode writes the image to w as a BMP; 24 bit if the image is opaque, otherwise 32 bit with an alpha channel
+// (using a version 4 header, so that other programs know the 4th byte is alpha)
+//
+func Encode(w io.Writer, m image.Image) error {
+	bounds := m.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	opaque := true
+	if o, ok := m.(interface{ Opaque() bool }); ok {
+		opaque = o.Opaque()
+	} else {
+		for y := bounds.Min.Y; y < bounds.Max.Y && opaque; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				if _, _, _, a := m.At(x, y).RGBA(); a != 0xFFFF {
+					opaque = false
+					break
+				}
+			}
+		}
+	}
+
+	bitCount, dibSize, compression := 24, infoHeaderSize, uint32(compressionRGB)
+	if !opaque {
+		bitCount, dibSize, compression = 32, v4HeaderSize, compressionBitFields
+	}
+
+	rowSize := ((width*bitCount + 31) / 32) * 4
+	pixelOffset := fileHeaderSize + dibSize
+
+	headers := make([]byte, pixelOffset)
+	copy(headers, "BM")
+	binary.LittleEndian.PutUint32(headers[2:], uint32(pixelOffset+rowSize*height))
+	binary.LittleEndian.PutUint32(headers[10:], uint32(pixelOffset))
+
+	dib := headers[fileHeaderSize:]
+	binary.LittleEndian.PutUint32(dib[0:], uint32(dibSize))
+	binary.LittleEndian.PutUint32(dib[4:], uint32(width))
+	binary.LittleEndian.PutUint32(dib[8:], uint32(height)) // (positive, so bottom up like most BMPs)
+	binary.LittleEndian.PutUint16(dib[12:], 1)
+	binary.LittleEndian.PutUint16(dib[14:], uint16(bitCount))
+	binary.LittleEndian.PutUint32(dib[16:], compression)
+	binary.LittleEndian.PutUint32(dib[20:], uint32(rowSize*height))
+	binary.LittleEndian.PutUint32(dib[24:], 2835) // 72 dpi, in pixels per metre
+	binary.LittleEndian.PutUint32(dib[28:], 2835)
+
+	if !opaque {
+		binary.LittleEndian.PutUint32(dib[40:], 0x00FF0000)
+		binary.LittleEndian.PutUint32(dib[44:], 0x0000FF00)
+		binary.LittleEndian.PutUint32(dib[48:], 0x000000FF)
+		binary.LittleEndian.PutUint32(dib[52:], 0xFF000000)
+		copy(dib[56:], "BGRs") // the sRGB colour space ("sRGB", little endian)
+	}
+
+	if _, err := w.Write(headers); err != nil {
+		return err
+	}
+
+	row := make([]byte, rowSize)
+	for y := bounds.Max.Y - 1; y >= bounds.Min.Y; y-- {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+			i := (x - bounds.Min.X) * bitCount / 8
+			row[i], row[i+1], row[i+2] = c.B, c.G, c.R
+			if bitCount == 32 {
+				row[i+3] = c.A
+			}
+		}
+
+		if _, err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
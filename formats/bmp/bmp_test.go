@@ -0,0 +1,198 @@
+package bmp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+//
+// testImage returns a 5x4 image (so the 24 bit rows need padding) with a different colour in every pixel, and if
+// alpha is set, a range of transparency
+//
+func testImage(alpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 5, 4))
+
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 5; x++ {
+			a := uint8(255)
+			if alpha {
+				a = uint8(x*60 + y)
+			}
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 50), uint8(y * 80), uint8(x*y*10 + 3), a})
+		}
+	}
+
+	return img
+}
+
+//
+// checkSame fails the test unless got has exactly the same (non premultiplied) pixels as want
+//
+func checkSame(t *testing.T, want, got image.Image) {
+	t.Helper()
+
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("got bounds %v, want %v", got.Bounds(), want.Bounds())
+	}
+
+	bounds := want.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c1 := color.NRGBAModel.Convert(want.At(x, y))
+			c2 := color.NRGBAModel.Convert(got.At(x, y))
+			if c1 != c2 {
+				t.Fatalf("pixel %v,%v: got %v, want %v", x, y, c2, c1)
+			}
+		}
+	}
+}
+
+//
+// encode encodes the image, failing the test if it can't
+//
+func encode(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		alpha    bool
+		bitCount int
+	}{
+		{"opaque", false, 24},
+		{"alpha", true, 32},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			img := testImage(test.alpha)
+			data := encode(t, img)
+
+			if bitCount := int(binary.LittleEndian.Uint16(data[fileHeaderSize+14:])); bitCount != test.bitCount {
+				t.Errorf("got a %v bit bmp, want %v bit", bitCount, test.bitCount)
+			}
+
+			config, err := DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Width != 5 || config.Height != 4 {
+				t.Errorf("got config %vx%v, want 5x4", config.Width, config.Height)
+			}
+
+			decoded, err := Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkSame(t, img, decoded)
+		})
+	}
+}
+
+func TestDecodeTopDown(t *testing.T) {
+	for _, alpha := range []bool{false, true} {
+		img := testImage(alpha)
+		data := encode(t, img)
+
+		// turn the (bottom up) bmp into a top down one; a negative height, and the rows the other way round
+		pixelOffset := int(binary.LittleEndian.Uint32(data[10:]))
+		rowSize := (len(data) - pixelOffset) / 4
+		topDown := append([]byte{}, data[:pixelOffset]...)
+		binary.LittleEndian.PutUint32(topDown[fileHeaderSize+8:], uint32(0xFFFFFFFC)) // -4
+		for row := 3; row >= 0; row-- {
+			topDown = append(topDown, data[pixelOffset+row*rowSize:pixelOffset+(row+1)*rowSize]...)
+		}
+
+		decoded, err := Decode(bytes.NewReader(topDown))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkSame(t, img, decoded)
+	}
+}
+
+func TestDecodePaletted(t *testing.T) {
+	// a 3x2, 1 bit bmp with a black and white palette (each row is padded to 4 bytes)
+	data := make([]byte, fileHeaderSize+infoHeaderSize+8+8)
+	copy(data, "BM")
+	binary.LittleEndian.PutUint32(data[2:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(data[10:], fileHeaderSize+infoHeaderSize+8)
+
+	dib := data[fileHeaderSize:]
+	binary.LittleEndian.PutUint32(dib[0:], infoHeaderSize)
+	binary.LittleEndian.PutUint32(dib[4:], 3)
+	binary.LittleEndian.PutUint32(dib[8:], 2)
+	binary.LittleEndian.PutUint16(dib[12:], 1)
+	binary.LittleEndian.PutUint16(dib[14:], 1)
+	binary.LittleEndian.PutUint32(dib[32:], 2)
+
+	copy(dib[infoHeaderSize+4:], []byte{255, 255, 255, 0})
+	dib[infoHeaderSize+8] = 0xA0   // bottom row: white, black, white
+	dib[infoHeaderSize+8+4] = 0x40 // top row: black, white, black
+
+	decoded, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := decoded.(*image.Paletted); !ok {
+		t.Errorf("got a %T, want an *image.Paletted", decoded)
+	}
+
+	want := image.NewGray(image.Rect(0, 0, 3, 2))
+	want.Pix = []uint8{0, 255, 0, 255, 0, 255}
+	checkSame(t, want, decoded)
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	for _, alpha := range []bool{false, true} {
+		data := encode(t, testImage(alpha))
+
+		for n := 0; n < len(data); n++ {
+			if _, err := Decode(bytes.NewReader(data[:n])); err == nil {
+				t.Errorf("expected an error decoding the first %v of %v bytes", n, len(data))
+			}
+		}
+	}
+}
+
+func TestDecodeBadHeaders(t *testing.T) {
+	put := binary.LittleEndian.PutUint32
+	tests := []struct {
+		name   string
+		change func(dib []byte)
+		err    error
+	}{
+		{"too big", func(dib []byte) { put(dib[4:], 100000); put(dib[8:], 100000) }, ErrFormat},
+		{"too big (top down)", func(dib []byte) { put(dib[4:], 100000); put(dib[8:], uint32(0xFFFE7960)) }, ErrFormat},
+		{"no width", func(dib []byte) { put(dib[4:], 0) }, ErrFormat},
+		{"negative width", func(dib []byte) { put(dib[4:], uint32(0xFFFFFFFB)) }, ErrFormat},
+		{"no height", func(dib []byte) { put(dib[8:], 0) }, ErrFormat},
+		{"bad bit count", func(dib []byte) { binary.LittleEndian.PutUint16(dib[14:], 7) }, ErrFormat},
+		{"rle compressed", func(dib []byte) { put(dib[16:], 1) }, ErrUnsupported},
+		{"bad header size", func(dib []byte) { put(dib[0:], 20) }, ErrFormat},
+	}
+
+	for _, test := range tests {
+		data := encode(t, testImage(false))
+		test.change(data[fileHeaderSize:])
+
+		if _, err := DecodeConfig(bytes.NewReader(data)); err != test.err {
+			t.Errorf("%v: DecodeConfig returned %v, want %v", test.name, err, test.err)
+		}
+		if _, err := Decode(bytes.NewReader(data)); err != test.err {
+			t.Errorf("%v: Decode returned %v, want %v", test.name, err, test.err)
+		}
+	}
+}
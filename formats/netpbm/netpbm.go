@@ -0,0 +1,580 @@
+//
+// Package netpbm reads and writes the Netpbm image formats; PBM (black and white), PGM (greyscale), PPM (colour)
+// in both their plain (ascii) and raw (binary) forms, and PAM (which can also have an alpha channel). Samples
+// can be 8 or 16 bits. Importing the package registers the decoders with the image package, so image.Decode
+// (and so monkey's LoadImageFromFile) can read them.
+//
+// See http://netpbm.sourceforge.net/doc/
+//
+package netpbm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//
+// Format is which of the Netpbm formats to write...
+//
+type Format int
+
+const (
+	// PPM is colour (red, green and blue)
+	PPM Format = iota
+
+	// PGM is greyscale
+	PGM
+
+	// PBM is black and white (anything darker than mid grey is black)
+	PBM
+
+	// PAM is red, green, blue and alpha
+	PAM
+)
+
+//
+// Options are the settings used by Encode...
+//
+type Options struct {
+	// Format is which format to write
+	Format Format
+
+	// ASCII writes the plain form (the samples as decimal text) rather than the raw (binary) form; it's much
+	// bigger, but can be read (and written) by hand. PAM only has a binary form.
+	ASCII bool
+
+	// SixteenBit writes 16 bit samples (a maxval of 65535) rather than 8 bit (255); PBM is always 1 bit
+	SixteenBit bool
+}
+
+//
+// ErrFormat is returned when the data isn't a Netpbm image we can read
+//
+var ErrFormat = errors.New("netpbm: invalid format")
+
+//
+// maxPixels is the most pixels an image we'll read can have (the same limit as the qoi package), so a corrupt
+// header can't make us allocate gigabytes
+//
+const maxPixels = 400000000
+
+//
+// header is what we know about an image once it's header has been read
+//
+type header struct {
+	magic  string // P1 to P7
+	width  int
+	height int
+	depth  int // the number of samples per pixel (1 grey, 2 grey + alpha, 3 rgb, 4 rgba)
+	maxval int
+}
+
+func init() {
+	image.RegisterFormat("pbm", "P1", Decode, DecodeConfig)
+	image.RegisterFormat("pbm", "P4", Decode, DecodeConfig)
+	image.RegisterFormat("pgm", "P2", Decode, DecodeConfig)
+	image.RegisterFormat("pgm", "P5", Decode, DecodeConfig)
+	image.RegisterFormat("ppm", "P3", Decode, DecodeConfig)
+	image.RegisterFormat("ppm", "P6", Decode, DecodeConfig)
+	image.RegisterFormat("pam", "P7", Decode, DecodeConfig)
+}
+
+//
+// DecodeConfig returns the dimensions and colour model of a Netpbm image without reading the whole thing
+//
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	h, err := readHeader(bufio.NewReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{ColorModel: h.colorModel(), Width: h.width, Height: h.height}, nil
+}
+
+//
+// Decode reads a Netpbm image. Greyscale (and black and white) images come back as an image.Gray (or Gray16 for
+// 16 bit samples), colour ones as an image.RGBA (RGBA64), and ones with an alpha channel as an image.NRGBA
+// (NRGBA64). Samples with a maxval other than 255 or 65535 are scaled to fit.
+//
+func Decode(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	h, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var readSample func() (int, error)
+	switch h.magic {
+	case "P1":
+		readSample = func() (int, error) { return readPlainBit(br) }
+	case "P2", "P3":
+		readSample = func() (int, error) { return readInt(br) }
+	case "P5", "P6", "P7":
+		readSample = func() (int, error) { return readRawSample(br, h.maxval) }
+	case "P4":
+		return decodeRawPBM(br, h)
+	}
+
+	img := h.newImage()
+	samples := make([]int, h.depth)
+
+	for y := 0; y < h.height; y++ {
+		for x := 0; x < h.width; x++ {
+			for i := range samples {
+				v, err := readSample()
+				if err != nil {
+					return nil, err
+				}
+
+				samples[i] = v
+			}
+
+			// (a 1 in a PBM is black, the opposite way round to everything else)
+			if h.magic == "P1" {
+				samples[0] = 1 - samples[0]
+			}
+
+			h.set(img, x, y, samples)
+		}
+	}
+
+	return img, nil
+}
+
+//
+// decodeRawPBM reads the raster of a binary PBM (8 pixels to a byte, each row starting on a new byte)
+//
+func decodeRawPBM(br *bufio.Reader, h header) (image.Image, error) {
+	img := image.NewGray(image.Rect(0, 0, h.width, h.height))
+	row := make([]byte, (h.width+7)/8)
+
+	for y := 0; y < h.height; y++ {
+		if _, err := io.ReadFull(br, row); err != nil {
+			return nil, err
+		}
+
+		for x := 0; x < h.width; x++ {
+			if row[x/8]&(0x80>>uint(x%8)) == 0 {
+				img.Pix[y*img.Stride+x] = 255
+			}
+		}
+	}
+
+	return img, nil
+}
+
+//
+// colorModel returns the colour model of the image Decode will return for the header
+//
+func (h header) colorModel() color.Model {
+	sixteenBit := h.maxval > 255
+
+	switch {
+	case h.depth <= 1 && sixteenBit:
+		return color.Gray16Model
+	case h.depth <= 1:
+		return color.GrayModel
+	case (h.depth == 2 || h.depth == 4) && sixteenBit:
+		return color.NRGBA64Model
+	case h.depth == 2 || h.depth == 4:
+		return color.NRGBAModel
+	case sixteenBit:
+		return color.RGBA64Model
+	}
+
+	return color.RGBAModel
+}
+
+//
+// newImage returns an empty image of the right type (see Decode) for the header
+//
+func (h header) newImage() image.Image {
+	bounds := image.Rect(0, 0, h.width, h.height)
+
+	switch h.colorModel() {
+	case color.Gray16Model:
+		return image.NewGray16(bounds)
+	case color.GrayModel:
+		return image.NewGray(bounds)
+	case color.NRGBA64Model:
+		return image.NewNRGBA64(bounds)
+	case color.NRGBAModel:
+		return image.NewNRGBA(bounds)
+	case color.RGBA64Model:
+		return image.NewRGBA64(bounds)
+	}
+
+	return image.NewRGBA(bounds)
+}
+
+//
+// set sets the pixel at x, y of the image (made by newImage) from the samples (scaled from 0-maxval)
+//
+func (h header) set(img image.Image, x, y int, samples []int) {
+	scale8 := func(v int) uint8 { return uint8((minInt(v, h.maxval)*255 + h.maxval/2) / h.maxval) }
+	scale16 := func(v int) uint16 { return uint16((minInt(v, h.maxval)*65535 + h.maxval/2) / h.maxval) }
+
+	switch img := img.(type) {
+	case *image.Gray:
+		img.SetGray(x, y, color.Gray{scale8(samples[0])})
+	case *image.Gray16:
+		img.SetGray16(x, y, color.Gray16{scale16(samples[0])})
+	case *image.RGBA:
+		img.SetRGBA(x, y, color.RGBA{scale8(samples[0]), scale8(samples[1]), scale8(samples[2]), 255})
+	case *image.RGBA64:
+		img.SetRGBA64(x, y, color.RGBA64{scale16(samples[0]), scale16(samples[1]), scale16(samples[2]), 65535})
+	case *image.NRGBA:
+		if h.depth == 2 {
+			grey := scale8(samples[0])
+			img.SetNRGBA(x, y, color.NRGBA{grey, grey, grey, scale8(samples[1])})
+		} else {
+			img.SetNRGBA(x, y, color.NRGBA{scale8(samples[0]), scale8(samples[1]), scale8(samples[2]), scale8(samples[3])})
+		}
+	case *image.NRGBA64:
+		if h.depth == 2 {
+			grey := scale16(samples[0])
+			img.SetNRGBA64(x, y, color.NRGBA64{grey, grey, grey, scale16(samples[1])})
+		} else {
+			img.SetNRGBA64(x, y, color.NRGBA64{scale16(samples[0]), scale16(samples[1]), scale16(samples[2]), scale16(samples[3])})
+		}
+	}
+}
+
+//
+// readHeader reads (and checks) the header, leaving the reader at the start of the raster
+//
+func readHeader(br *bufio.Reader) (header, error) {
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return header{}, err
+	}
+
+	h := header{magic: string(magic), depth: 1, maxval: 1}
+	var err error
+
+	switch h.magic {
+	case "P7":
+		err = readPAMHeader(br, &h)
+	case "P1", "P4":
+		if h.width, err = readInt(br); err == nil {
+			h.height, err = readInt(br)
+		}
+	case "P2", "P5", "P3", "P6":
+		if h.magic == "P3" || h.magic == "P6" {
+			h.depth = 3
+		}
+
+		if h.width, err = readInt(br); err == nil {
+			if h.height, err = readInt(br); err == nil {
+				h.maxval, err = readInt(br)
+			}
+		}
+	default:
+		return h, ErrFormat
+	}
+
+	if err != nil {
+		return h, err
+	}
+
+	if h.width <= 0 || h.height <= 0 || h.height >= maxPixels/h.width || h.maxval < 1 || h.maxval > 65535 || h.depth < 1 || h.depth > 4 {
+		return h, ErrFormat
+	}
+
+	return h, nil
+}
+
+//
+// readPAMHeader reads the "KEY value" lines of a PAM header up to ENDHDR
+//
+func readPAMHeader(br *bufio.Reader, h *header) error {
+	h.depth = 0
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if fields[0] == "ENDHDR" {
+			return nil
+		}
+
+		if len(fields) < 2 {
+			return ErrFormat
+		}
+
+		value, _ := strconv.Atoi(fields[1])
+		switch fields[0] {
+		case "WIDTH":
+			h.width = value
+		case "HEIGHT":
+			h.height = value
+		case "DEPTH":
+			h.depth = value
+		case "MAXVAL":
+			h.maxval = value
+		}
+	}
+}
+
+//
+// skipSpaceAndComments skips whitespace and comments (from a # to the end of the line) in the header or a plain
+// raster
+//
+func skipSpaceAndComments(br *bufio.Reader) error {
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		if c == '#' {
+			if _, err := br.ReadString('\n'); err != nil {
+				return err
+			}
+		} else if !isSpace(c) {
+			return br.UnreadByte()
+		}
+	}
+}
+
+//
+// readInt reads a decimal number (and the single whitespace character after it, which in a raw file is the
+// last byte before the raster)
+//
+func readInt(br *bufio.Reader) (int, error) {
+	if err := skipSpaceAndComments(br); err != nil {
+		return 0, err
+	}
+
+	digits := []byte{}
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF && len(digits) > 0 {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+
+		if isSpace(c) {
+			break
+		}
+
+		if c < '0' || c > '9' {
+			return 0, ErrFormat
+		}
+
+		digits = append(digits, c)
+	}
+
+	return strconv.Atoi(string(digits))
+}
+
+//
+// readPlainBit reads a single 0 or 1 from a plain PBM raster (they don't have to be separated by whitespace)
+//
+func readPlainBit(br *bufio.Reader) (int, error) {
+	if err := skipSpaceAndComments(br); err != nil {
+		return 0, err
+	}
+
+	c, err := br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	if c != '0' && c != '1' {
+		return 0, ErrFormat
+	}
+
+	return int(c - '0'), nil
+}
+
+//
+// readRawSample reads a binary sample (1 byte, or 2 big endian bytes if maxval is over 255)
+//
+func readRawSample(br *bufio.Reader, maxval int) (int, error) {
+	high, err := br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	if maxval < 256 {
+		return int(high), nil
+	}
+
+	low, err := br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(high)<<8 | int(low), nil
+}
+
+//
+// Encode writes the image to w in a Netpbm format (the options say which; nil means a binary 8 bit PPM).
+// PPM and PGM have no alpha channel, so the colours are written as if the image were on a black background.
+//
+func Encode(w io.Writer, m image.Image, o *Options) error {
+	options := Options{}
+	if o != nil {
+		options = *o
+	}
+
+	bounds := m.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	maxval := 255
+	if options.SixteenBit {
+		maxval = 65535
+	}
+
+	bw := bufio.NewWriter(w)
+
+	switch options.Format {
+	case PBM:
+		magic := "P4"
+		if options.ASCII {
+			magic = "P1"
+		}
+		fmt.Fprintf(bw, "%v\n%v %v\n", magic, width, height)
+	case PGM, PPM:
+		magic := map[Format]string{PGM: "P5", PPM: "P6"}[options.Format]
+		if options.ASCII {
+			magic = map[Format]string{PGM: "P2", PPM: "P3"}[options.Format]
+		}
+		fmt.Fprintf(bw, "%v\n%v %v\n%v\n", magic, width, height, maxval)
+	case PAM:
+		if options.ASCII {
+			return errors.New("netpbm: PAM has no plain (ascii) form")
+		}
+		fmt.Fprintf(bw, "P7\nWIDTH %v\nHEIGHT %v\nDEPTH 4\nMAXVAL %v\nTUPLTYPE RGB_ALPHA\nENDHDR\n", width, height, maxval)
+	default:
+		return fmt.Errorf("netpbm: unknown format %v", int(options.Format))
+	}
+
+	// writeSample writes a sample (0-65535, scaled down to maxval) in the plain or raw form...
+	column := 0
+	writeSample := func(v uint32) {
+		if maxval == 255 {
+			v >>= 8
+		}
+
+		switch {
+		case options.ASCII:
+			// (plain files shouldn't have lines longer than 70 characters)
+			text := strconv.Itoa(int(v))
+			if column+len(text) >= 70 {
+				bw.WriteByte('\n')
+				column = 0
+			} else if column > 0 {
+				bw.WriteByte(' ')
+				column++
+			}
+			bw.WriteString(text)
+			column += len(text)
+		case maxval == 255:
+			bw.WriteByte(byte(v))
+		default:
+			bw.WriteByte(byte(v >> 8))
+			bw.WriteByte(byte(v))
+		}
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		if options.Format == PBM {
+			writePBMRow(bw, m, y, options.ASCII)
+			continue
+		}
+
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			switch options.Format {
+			case PGM:
+				writeSample(uint32(color.Gray16Model.Convert(m.At(x, y)).(color.Gray16).Y))
+			case PPM:
+				r, g, b, _ := m.At(x, y).RGBA()
+				writeSample(r)
+				writeSample(g)
+				writeSample(b)
+			case PAM:
+				c := color.NRGBA64Model.Convert(m.At(x, y)).(color.NRGBA64)
+				writeSample(uint32(c.R))
+				writeSample(uint32(c.G))
+				writeSample(uint32(c.B))
+				writeSample(uint32(c.A))
+			}
+		}
+	}
+
+	if options.ASCII {
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+//
+// writePBMRow writes a row of a PBM (a pixel is black, 1, if it's darker than mid grey)
+//
+func writePBMRow(bw *bufio.Writer, m image.Image, y int, ascii bool) {
+	bounds := m.Bounds()
+	var current byte
+
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		bit := byte(0)
+		if color.GrayModel.Convert(m.At(x, y)).(color.Gray).Y < 128 {
+			bit = 1
+		}
+
+		i := x - bounds.Min.X
+		if ascii {
+			// (35 pixels, and their spaces, to a line)
+			if i > 0 && i%35 == 0 {
+				bw.WriteByte('\n')
+			} else if i > 0 {
+				bw.WriteByte(' ')
+			}
+			bw.WriteByte('0' + bit)
+			continue
+		}
+
+		current |= bit << uint(7-i%8)
+		if i%8 == 7 || x == bounds.Max.X-1 {
+			bw.WriteByte(current)
+			current = 0
+		}
+	}
+
+	if ascii {
+		bw.WriteByte('\n')
+	}
+}
+
+//
+// isSpace returns true for the characters Netpbm treats as whitespace
+//
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+//
+// minInt returns the smaller of a and b
+//
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,161 @@
+package netpbm
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+//
+// testImage returns a small image with a different colour in every pixel (and, if alpha is set, a range of
+// transparency); it's 11 pixels wide so the PBM rows don't fill a whole number of bytes
+//
+func testImage(alpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 11, 3))
+
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 11; x++ {
+			a := uint8(255)
+			if alpha {
+				a = uint8(x * 25)
+			}
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 23), uint8(y * 100), uint8(255 - x*20), a})
+		}
+	}
+
+	return img
+}
+
+//
+// checkSame fails the test if any pixel of got is more than tolerance (out of 65535) away from want's
+//
+func checkSame(t *testing.T, want, got image.Image, tolerance int) {
+	t.Helper()
+
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("got bounds %v, want %v", got.Bounds(), want.Bounds())
+	}
+
+	bounds := want.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r1, g1, b1, a1 := want.At(x, y).RGBA()
+			r2, g2, b2, a2 := got.At(x, y).RGBA()
+
+			for i, d := range []int{int(r1) - int(r2), int(g1) - int(g2), int(b1) - int(b2), int(a1) - int(a2)} {
+				if d > tolerance || d < -tolerance {
+					t.Fatalf("pixel %v,%v channel %v: got %v, want %v", x, y, i, got.At(x, y), want.At(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	opaque := testImage(false)
+	grey := image.NewGray(opaque.Bounds())
+	blackAndWhite := image.NewGray(opaque.Bounds())
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 11; x++ {
+			grey.SetGray(x, y, color.Gray{uint8(x*20 + y)})
+			if (x+y)%3 == 0 {
+				blackAndWhite.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		img       image.Image
+		options   Options
+		model     color.Model
+		tolerance int
+	}{
+		{"raw pbm", blackAndWhite, Options{Format: PBM}, color.GrayModel, 0},
+		{"plain pbm", blackAndWhite, Options{Format: PBM, ASCII: true}, color.GrayModel, 0},
+		{"raw pgm", grey, Options{Format: PGM}, color.GrayModel, 0},
+		{"plain pgm", grey, Options{Format: PGM, ASCII: true}, color.GrayModel, 0},
+		{"16 bit pgm", grey, Options{Format: PGM, SixteenBit: true}, color.Gray16Model, 0},
+		{"16 bit plain pgm", grey, Options{Format: PGM, ASCII: true, SixteenBit: true}, color.Gray16Model, 0},
+		{"raw ppm", opaque, Options{Format: PPM}, color.RGBAModel, 0},
+		{"plain ppm", opaque, Options{Format: PPM, ASCII: true}, color.RGBAModel, 0},
+		{"16 bit ppm", opaque, Options{Format: PPM, SixteenBit: true}, color.RGBA64Model, 0},
+		{"16 bit plain ppm", opaque, Options{Format: PPM, ASCII: true, SixteenBit: true}, color.RGBA64Model, 0},
+		{"pam", testImage(true), Options{Format: PAM}, color.NRGBAModel, 257},
+		{"16 bit pam", testImage(true), Options{Format: PAM, SixteenBit: true}, color.NRGBA64Model, 257},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := test.options
+			buffer := &bytes.Buffer{}
+			if err := Encode(buffer, test.img, &options); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := DecodeConfig(bytes.NewReader(buffer.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Width != 11 || config.Height != 3 {
+				t.Errorf("got config %vx%v, want 11x3", config.Width, config.Height)
+			}
+			if config.ColorModel != test.model {
+				t.Error("got the wrong colour model")
+			}
+
+			decoded, err := Decode(buffer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkSame(t, test.img, decoded, test.tolerance)
+		})
+	}
+}
+
+func TestEncodePlainPAM(t *testing.T) {
+	if err := Encode(&bytes.Buffer{}, testImage(true), &Options{Format: PAM, ASCII: true}); err == nil {
+		t.Error("expected an error, as PAM has no plain form")
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	for _, options := range []Options{{Format: PBM}, {Format: PGM}, {Format: PPM, SixteenBit: true}, {Format: PAM}} {
+		buffer := &bytes.Buffer{}
+		if err := Encode(buffer, testImage(true), &options); err != nil {
+			t.Fatal(err)
+		}
+
+		data := buffer.Bytes()
+		for n := 0; n < len(data); n++ {
+			if _, err := Decode(bytes.NewReader(data[:n])); err == nil {
+				t.Errorf("format %v: expected an error decoding the first %v of %v bytes", options.Format, n, len(data))
+			}
+		}
+	}
+}
+
+func TestDecodeBadHeaders(t *testing.T) {
+	headers := []string{
+		"P6\n1073741824 1073741824\n255\n",
+		"P5\n100000 100000\n255\n",
+		"P7\nWIDTH 65536\nHEIGHT 65536\nDEPTH 4\nMAXVAL 255\nENDHDR\n",
+		"P6\n0 10\n255\n",
+		"P6\n10 10\n0\n",
+		"P6\n10 10\n65536\n",
+		"P7\nWIDTH 1\nHEIGHT 1\nDEPTH 5\nMAXVAL 255\nENDHDR\n",
+		"P6\n99999999999999999999999 1\n255\n",
+		"P9\n1 1\n255\n",
+	}
+
+	for _, h := range headers {
+		if _, err := DecodeConfig(bytes.NewReader([]byte(h))); err == nil {
+			t.Errorf("DecodeConfig: expected an error for %q", h)
+		}
+		if _, err := Decode(bytes.NewReader([]byte(h))); err == nil {
+			t.Errorf("Decode: expected an error for %q", h)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+//
+// Package qoi reads and writes QOI ("Quite OK Image") images; a simple lossless format that compresses about as
+// well as png, but is much quicker to encode and decode. Importing the package registers the decoder with the
+// image package.
+//
+// See https://qoiformat.org/qoi-specification.pdf
+//
+package qoi
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+//
+// ErrFormat is returned when the data isn't a QOI image we can read
+//
+var ErrFormat = errors.New("qoi: invalid format")
+
+const (
+	magic      = "qoif"
+	headerSize = 14
+
+	// the images we'll read are limited to this many pixels (like the reference implementation), so a corrupt
+	// header can't make us allocate gigabytes
+	maxPixels = 400000000
+)
+
+//
+// The chunk tags; the 2 bit ones are in the top 2 bits of the first byte, the 8 bit ones are the whole byte...
+//
+const (
+	opIndex = 0x00
+	opDiff  = 0x40
+	opLuma  = 0x80
+	opRun   = 0xC0
+	opRGB   = 0xFE
+	opRGBA  = 0xFF
+	opMask  = 0xC0
+)
+
+//
+// endMarker is written after the last chunk
+//
+var endMarker = []byte{0, 0, 0, 0, 0, 0, 0, 1}
+
+func init() {
+	image.RegisterFormat("qoi", magic, Decode, DecodeConfig)
+}
+
+//
+// DecodeConfig returns the dimensions and colour model of a QOI image without reading the pixels
+//
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	width, height, _, err := readHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{ColorModel: color.NRGBAModel, Width: width, Height: height}, nil
+}
+
+//
+// Decode reads a QOI image, returning it as an image.NRGBA
+//
+func Decode(r io.Reader) (image.Image, error) {
+	width, height, _, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(r)
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	var index [64]color.NRGBA
+	px := color.NRGBA{0, 0, 0, 255}
+	run := 0
+
+	for i := 0; i < len(img.Pix); i += 4 {
+		if run > 0 {
+			run--
+		} else {
+			b, err := br.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case b == opRGB:
+				if err := readBytes(br, &px.R, &px.G, &px.B); err != nil {
+					return nil, err
+				}
+			case b == opRGBA:
+				if err := readBytes(br, &px.R, &px.G, &px.B, &px.A); err != nil {
+					return nil, err
+				}
+			case b&opMask == opIndex:
+				px = index[b]
+			case b&opMask == opDiff:
+				px.R += (b>>4)&0x03 - 2
+				px.G += (b>>2)&0x03 - 2
+				px.B += b&0x03 - 2
+			case b&opMask == opLuma:
+				b2, err := br.ReadByte()
+				if err != nil {
+					return nil, err
+				}
+
+				dg := b&0x3F - 32
+				px.R += dg - 8 + (b2>>4)&0x0F
+				px.G += dg
+				px.B += dg - 8 + b2&0x0F
+			case b&opMask == opRun:
+				run = int(b & 0x3F)
+			}
+
+			index[hash(px)] = px
+		}
+
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = px.R, px.G, px.B, px.A
+	}
+
+	return img, nil
+}
+
+//
+// readHeader reads the header, returning the dimensions and the number of channels (3 or 4)
+//
+func readHeader(r io.Reader) (int, int, int, error) {
+	b := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return 0, 0, 0, err
+	}
+
+	if string(b[:4]) != magic {
+		return 0, 0, 0, ErrFormat
+	}
+
+	width := int(binary.BigEndian.Uint32(b[4:]))
+	height := int(binary.BigEndian.Uint32(b[8:]))
+	channels := int(b[12])
+
+	if width == 0 || height == 0 || height >= maxPixels/width || (channels != 3 && channels != 4) {
+		return 0, 0, 0, ErrFormat
+	}
+
+	return width, height, channels, nil
+}
+
+//
+// readBytes reads a byte into each of the given places...
+//
+func readBytes(br *bufio.Reader, bytes ...*uint8) error {
+	for _, b := range bytes {
+		var err error
+		if *b, err = br.ReadByte(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//
+// hash is where a colour goes in the index of previously seen colours
+//
+func hash(c color.NRGBA) int {
+	return (int(c.R)*3 + int(c.G)*5 + int(c.B)*7 + int(c.A)*11) % 64
+}
+
+//
+// Encode writes the image to w as a QOI image; with 4 channels if it has any transparency, 3 if it doesn't
+//
+func Encode(w io.Writer, m image.Image) error {
+	bounds := m.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 || height >= maxPixels/width {
+		return errors.New("qoi: image is empty or too big")
+	}
+
+	// convert the image up front, so we know whether it needs an alpha channel
+	pixels := make([]color.NRGBA, 0, width*height)
+	channels := byte(3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+			if c.A != 255 {
+				channels = 4
+			}
+			pixels = append(pixels, c)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	binary.BigEndian.PutUint32(header[4:], uint32(width))
+	binary.BigEndian.PutUint32(header[8:], uint32(height))
+	header[12] = channels
+	header[13] = 0 // sRGB with linear alpha
+	bw.Write(header)
+
+	var index [64]color.NRGBA
+	prev := color.NRGBA{0, 0, 0, 255}
+	run := 0
+
+	for i, px := range pixels {
+		if px == prev {
+			run++
+			if run == 62 || i == len(pixels)-1 {
+				bw.WriteByte(opRun | byte(run-1))
+				run = 0
+			}
+			continue
+		}
+
+		if run > 0 {
+			bw.WriteByte(opRun | byte(run-1))
+			run = 0
+		}
+
+		h := hash(px)
+		switch {
+		case index[h] == px:
+			bw.WriteByte(opIndex | byte(h))
+		case px.A != prev.A:
+			bw.Write([]byte{opRGBA, px.R, px.G, px.B, px.A})
+		default:
+			// the differences wrap around, so they are worked out as bytes and then treated as signed
+			dr := int8(px.R - prev.R)
+			dg := int8(px.G - prev.G)
+			db := int8(px.B - prev.B)
+			drdg := dr - dg
+			dbdg := db - dg
+
+			switch {
+			case dr >= -2 && dr <= 1 && dg >= -2 && dg <= 1 && db >= -2 && db <= 1:
+				bw.WriteByte(opDiff | byte(dr+2)<<4 | byte(dg+2)<<2 | byte(db+2))
+			case dg >= -32 && dg <= 31 && drdg >= -8 && drdg <= 7 && dbdg >= -8 && dbdg <= 7:
+				bw.Write([]byte{opLuma | byte(dg+32), byte(drdg+8)<<4 | byte(dbdg+8)})
+			default:
+				bw.Write([]byte{opRGB, px.R, px.G, px.B})
+			}
+		}
+
+		index[h] = px
+		prev = px
+	}
+
+	bw.Write(endMarker)
+
+	return bw.Flush()
+}
@@ -0,0 +1,129 @@
+package qoi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+//
+// testImage returns a 100x4 image that makes the encoder use every kind of chunk; a run longer than a run chunk
+// can hold, small and larger changes from pixel to pixel, colours it's seen before and (if alpha is set) changes
+// of transparency
+//
+func testImage(alpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 100, 4))
+
+	for x := 0; x < 100; x++ {
+		img.SetNRGBA(x, 0, color.NRGBA{200, 100, 50, 255})
+		img.SetNRGBA(x, 1, color.NRGBA{uint8(x), uint8(x * 2), uint8(x), 255})
+		img.SetNRGBA(x, 2, color.NRGBA{uint8(x * 37), uint8(x * 91), uint8(x * 13), 255})
+		img.SetNRGBA(x, 3, img.NRGBAAt(x%5, 2))
+
+		if alpha {
+			img.Pix[img.PixOffset(x, 3)+3] = uint8(x * 2)
+		}
+	}
+
+	return img
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		alpha    bool
+		channels byte
+	}{
+		{"opaque", false, 3},
+		{"alpha", true, 4},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			img := testImage(test.alpha)
+			buffer := &bytes.Buffer{}
+			if err := Encode(buffer, img); err != nil {
+				t.Fatal(err)
+			}
+
+			data := buffer.Bytes()
+			if data[12] != test.channels {
+				t.Errorf("got %v channels, want %v", data[12], test.channels)
+			}
+			if !bytes.HasSuffix(data, endMarker) {
+				t.Error("the end marker is missing")
+			}
+
+			config, err := DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Width != 100 || config.Height != 4 {
+				t.Errorf("got config %vx%v, want 100x4", config.Width, config.Height)
+			}
+
+			decoded, err := Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(decoded.(*image.NRGBA).Pix, img.Pix) {
+				t.Error("the decoded pixels are different")
+			}
+		})
+	}
+}
+
+func TestEncodeEmpty(t *testing.T) {
+	if err := Encode(&bytes.Buffer{}, image.NewNRGBA(image.Rect(0, 0, 0, 10))); err == nil {
+		t.Error("expected an error encoding an empty image")
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, testImage(true)); err != nil {
+		t.Fatal(err)
+	}
+
+	// (the end marker isn't needed to decode the pixels, so only cutting into the chunks has to fail)
+	data := buffer.Bytes()
+	for n := 0; n < len(data)-len(endMarker); n++ {
+		if _, err := Decode(bytes.NewReader(data[:n])); err == nil {
+			t.Errorf("expected an error decoding the first %v of %v bytes", n, len(data))
+		}
+	}
+}
+
+func TestDecodeBadHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(h []byte)
+	}{
+		{"too big", func(h []byte) {
+			binary.BigEndian.PutUint32(h[4:], 1<<30)
+			binary.BigEndian.PutUint32(h[8:], 1<<30)
+		}},
+		{"no width", func(h []byte) { binary.BigEndian.PutUint32(h[4:], 0) }},
+		{"no height", func(h []byte) { binary.BigEndian.PutUint32(h[8:], 0) }},
+		{"bad channels", func(h []byte) { h[12] = 5 }},
+		{"bad magic", func(h []byte) { copy(h, "qoij") }},
+	}
+
+	for _, test := range tests {
+		buffer := &bytes.Buffer{}
+		if err := Encode(buffer, testImage(false)); err != nil {
+			t.Fatal(err)
+		}
+
+		data := buffer.Bytes()
+		test.change(data)
+
+		if _, err := DecodeConfig(bytes.NewReader(data)); err != ErrFormat {
+			t.Errorf("%v: DecodeConfig returned %v, want %v", test.name, err, ErrFormat)
+		}
+		if _, err := Decode(bytes.NewReader(data)); err != ErrFormat {
+			t.Errorf("%v: Decode returned %v, want %v", test.name, err, ErrFormat)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+//
+// Package tga reads and writes Truevision TGA (Targa) images. It reads colour mapped, true colour and
+// greyscale images at 8, 15/16, 24 and 32 bits per pixel, either uncompressed or run length encoded, and in
+// any of the four origins (corners). It writes 32 bit true colour images with an alpha channel, uncompressed
+// or RLE. Importing the package registers the decoder with the image package.
+//
+// TGA doesn't have a magic number, so the decoder is registered against the header's colour map and image
+// type bytes; that's enough to tell it apart from the other formats image.Decode knows about.
+//
+// See http://www.paulbourke.net/dataformats/tga/
+//
+package tga
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"io/ioutil"
+)
+
+//
+// Options are the settings used by Encode...
+//
+type Options struct {
+	// RLE run length encodes the pixels; it's smaller for images with runs of the same colour (and bigger, by a
+	// byte every 128 pixels, for images without)
+	RLE bool
+}
+
+//
+// ErrFormat is returned when the data isn't a TGA we can read
+//
+var ErrFormat = errors.New("tga: invalid format")
+
+//
+// The image types...
+//
+const (
+	typeColourMapped    = 1
+	typeTrueColour      = 2
+	typeGrey            = 3
+	typeRLEColourMapped = 9
+	typeRLETrueColour   = 10
+	typeRLEGrey         = 11
+)
+
+const (
+	headerSize = 18
+
+	// the most pixels we'll read; the header allows 65535 x 65535, which would be 16GB of pixels
+	maxPixels = 400000000
+)
+
+//
+// The bits of the image descriptor byte...
+//
+const (
+	descriptorAlphaBits = 0x0F // how many bits of each pixel are alpha (8 for 32 bit, 1 for 16 bit)
+	descriptorRightLeft = 0x10
+	descriptorTopBottom = 0x20
+)
+
+//
+// The bits of an RLE packet's first byte...
+//
+const (
+	rlePacketFlag       = 0x80
+	rlePacketLengthMask = 0x7F
+	maxRLEPacketLength  = 128
+)
+
+//
+// header is what we need from the header (and colour map) to read the pixels
+//
+type header struct {
+	idLength      int
+	colourMapType int
+	imageType     int
+	width, height int
+	pixelDepth    int
+	descriptor    int
+	palette       []color.NRGBA
+}
+
+func init() {
+	// (the first byte is the length of the image id, so it can be anything)
+	image.RegisterFormat("tga", "?\x01\x01", Decode, DecodeConfig)
+	image.RegisterFormat("tga", "?\x00\x02", Decode, DecodeConfig)
+	image.RegisterFormat("tga", "?\x00\x03", Decode, DecodeConfig)
+	image.RegisterFormat("tga", "?\x01\x09", Decode, DecodeConfig)
+	image.RegisterFormat("tga", "?\x00\x0A", Decode, DecodeConfig)
+	image.RegisterFormat("tga", "?\x00\x0B", Decode, DecodeConfig)
+}
+
+//
+// DecodeConfig returns the dimensions and colour model of a TGA without reading the pixels
+//
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{ColorModel: color.NRGBAModel, Width: h.width, Height: h.height}, nil
+}
+
+//
+// Decode reads a TGA, returning it as an image.NRGBA
+//
+func Decode(r io.Reader) (image.Image, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bytesPerPixel := (h.pixelDepth + 7) / 8
+	data := make([]byte, h.width*h.height*bytesPerPixel)
+
+	if h.imageType >= typeRLEColourMapped {
+		if err := readRLE(r, data, bytesPerPixel); err != nil {
+			return nil, err
+		}
+	} else if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	// the alpha channel only counts if the descriptor says it has alpha bits (lots of 32 bit TGAs are written
+	// with zeros in the 4th byte, and would otherwise come out invisible)
+	hasAlpha := h.descriptor&descriptorAlphaBits != 0
+
+	img := image.NewNRGBA(image.Rect(0, 0, h.width, h.height))
+	for i := 0; i < h.width*h.height; i++ {
+		pixel := data[i*bytesPerPixel : (i+1)*bytesPerPixel]
+
+		var c color.NRGBA
+		switch h.imageType {
+		case typeColourMapped, typeRLEColourMapped:
+			index := int(pixel[0])
+			if bytesPerPixel == 2 {
+				index = int(binary.LittleEndian.Uint16(pixel))
+			}
+			if index < len(h.palette) {
+				c = h.palette[index]
+			}
+		case typeGrey, typeRLEGrey:
+			c = color.NRGBA{pixel[0], pixel[0], pixel[0], 255}
+			if bytesPerPixel == 2 && hasAlpha {
+				c.A = pixel[1]
+			}
+		default:
+			c = pixelColour(pixel, hasAlpha)
+		}
+
+		// the pixels are stored bottom up (unless the descriptor says otherwise) and left to right (ditto)
+		x, y := i%h.width, i/h.width
+		if h.descriptor&descriptorRightLeft != 0 {
+			x = h.width - 1 - x
+		}
+		if h.descriptor&descriptorTopBottom == 0 {
+			y = h.height - 1 - y
+		}
+
+		img.SetNRGBA(x, y, c)
+	}
+
+	return img, nil
+}
+
+//
+// readHeader reads the header, skips the image id and reads the colour map (if there is one)
+//
+func readHeader(r io.Reader) (header, error) {
+	h := header{}
+
+	b := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return h, err
+	}
+
+	h.idLength = int(b[0])
+	h.colourMapType = int(b[1])
+	h.imageType = int(b[2])
+	firstEntry := int(binary.LittleEndian.Uint16(b[3:]))
+	numEntries := int(binary.LittleEndian.Uint16(b[5:]))
+	entryDepth := int(b[7])
+	h.width = int(binary.LittleEndian.Uint16(b[12:]))
+	h.height = int(binary.LittleEndian.Uint16(b[14:]))
+	h.pixelDepth = int(b[16])
+	h.descriptor = int(b[17])
+
+	if h.width == 0 || h.height == 0 || h.height >= maxPixels/h.width || h.colourMapType > 1 {
+		return h, ErrFormat
+	}
+
+	switch h.imageType {
+	case typeColourMapped, typeRLEColourMapped:
+		if h.colourMapType != 1 || (h.pixelDepth != 8 && h.pixelDepth != 16) {
+			return h, ErrFormat
+		}
+	case typeTrueColour, typeRLETrueColour:
+		if h.pixelDepth != 15 && h.pixelDepth != 16 && h.pixelDepth != 24 && h.pixelDepth != 32 {
+			return h, ErrFormat
+		}
+	case typeGrey, typeRLEGrey:
+		if h.pixelDepth != 8 && h.pixelDepth != 16 {
+			return h, ErrFormat
+		}
+	default:
+		return h, ErrFormat
+	}
+
+	if _, err := io.CopyN(ioutil.Discard, r, int64(h.idLength)); err != nil {
+		return h, err
+	}
+
+	if h.colourMapType == 1 {
+		entrySize := (entryDepth + 7) / 8
+		if entrySize < 2 || entrySize > 4 {
+			return h, ErrFormat
+		}
+
+		entries := make([]byte, numEntries*entrySize)
+		if _, err := io.ReadFull(r, entries); err != nil {
+			return h, err
+		}
+
+		// colour map indexes start at firstEntry, so pad the palette up to it
+		h.palette = make([]color.NRGBA, firstEntry, firstEntry+numEntries)
+		for i := 0; i < numEntries; i++ {
+			h.palette = append(h.palette, pixelColour(entries[i*entrySize:(i+1)*entrySize], entryDepth == 32))
+		}
+	}
+
+	return h, nil
+}
+
+//
+// readRLE fills data from the run length encoded packets in r; each packet starts with a byte whose top bit
+// says whether it's a run (one pixel, repeated) or raw (that many pixels), and whose other bits are the count - 1
+//
+func readRLE(r io.Reader, data []byte, bytesPerPixel int) error {
+	packet := make([]byte, 1)
+	pixel := make([]byte, bytesPerPixel)
+
+	for i := 0; i < len(data); {
+		if _, err := io.ReadFull(r, packet); err != nil {
+			return err
+		}
+
+		count := int(packet[0]&rlePacketLengthMask) + 1
+		if i+count*bytesPerPixel > len(data) {
+			return ErrFormat
+		}
+
+		if packet[0]&rlePacketFlag != 0 {
+			if _, err := io.ReadFull(r, pixel); err != nil {
+				return err
+			}
+
+			for n := 0; n < count; n++ {
+				copy(data[i:], pixel)
+				i += bytesPerPixel
+			}
+		} else {
+			if _, err := io.ReadFull(r, data[i:i+count*bytesPerPixel]); err != nil {
+				return err
+			}
+			i += count * bytesPerPixel
+		}
+	}
+
+	return nil
+}
+
+//
+// pixelColour turns a 2 (ARRRRRGG GGGBBBBB, little endian), 3 (BGR) or 4 (BGRA) byte pixel into a colour
+//
+func pixelColour(pixel []byte, hasAlpha bool) color.NRGBA {
+	switch len(pixel) {
+	case 2:
+		v := binary.LittleEndian.Uint16(pixel)
+		c := color.NRGBA{expand5(v >> 10), expand5(v >> 5), expand5(v), 255}
+		if hasAlpha && v&0x8000 == 0 {
+			c.A = 0
+		}
+		return c
+	case 3:
+		return color.NRGBA{pixel[2], pixel[1], pixel[0], 255}
+	}
+
+	a := uint8(255)
+	if hasAlpha {
+		a = pixel[3]
+	}
+
+	return color.NRGBA{pixel[2], pixel[1], pixel[0], a}
+}
+
+//
+// expand5 scales the bottom 5 bits of v up to 0-255
+//
+func expand5(v uint16) uint8 {
+	v &= 0x1F
+	return uint8(v<<3 | v>>2)
+}
+
+//
+// Encode writes the image to w as a 32 bit (BGRA) true colour TGA, stored top down; a nil Options writes it
+// uncompressed
+//
+func Encode(w io.Writer, m image.Image, o *Options) error {
+	bounds := m.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > 0xFFFF || height > 0xFFFF {
+		return errors.New("tga: image is too big")
+	}
+
+	rle := o != nil && o.RLE
+
+	h := make([]byte, headerSize)
+	h[2] = typeTrueColour
+	if rle {
+		h[2] = typeRLETrueColour
+	}
+	binary.LittleEndian.PutUint16(h[12:], uint16(width))
+	binary.LittleEndian.PutUint16(h[14:], uint16(height))
+	h[16] = 32
+	h[17] = descriptorTopBottom | 8
+
+	if _, err := w.Write(h); err != nil {
+		return err
+	}
+
+	row := make([]byte, width*4)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+			i := (x - bounds.Min.X) * 4
+			row[i], row[i+1], row[i+2], row[i+3] = c.B, c.G, c.R, c.A
+		}
+
+		out := row
+		if rle {
+			// (packets don't cross rows, which is what the spec recommends)
+			out = encodeRLE(row, 4)
+		}
+
+		if _, err := w.Write(out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//
+// encodeRLE run length encodes a row of pixels; runs of 2 or more of the same pixel become run packets, and
+// everything in between goes into raw packets
+//
+func encodeRLE(row []byte, bytesPerPixel int) []byte {
+	out := []byte{}
+	numPixels := len(row) / bytesPerPixel
+	pixel := func(i int) string { return string(row[i*bytesPerPixel : (i+1)*bytesPerPixel]) }
+
+	rawStart := 0
+	flushRaw := func(end int) {
+		for rawStart < end {
+			count := end - rawStart
+			if count > maxRLEPacketLength {
+				count = maxRLEPacketLength
+			}
+
+			out = append(out, byte(count-1))
+			out = append(out, row[rawStart*bytesPerPixel:(rawStart+count)*bytesPerPixel]...)
+			rawStart += count
+		}
+	}
+
+	for i := 0; i < numPixels; {
+		run := 1
+		for i+run < numPixels && run < maxRLEPacketLength && pixel(i+run) == pixel(i) {
+			run++
+		}
+
+		if run < 2 {
+			i++
+			continue
+		}
+
+		flushRaw(i)
+		out = append(out, rlePacketFlag|byte(run-1))
+		out = append(out, row[i*bytesPerPixel:(i+1)*bytesPerPixel]...)
+		i += run
+		rawStart = i
+	}
+
+	flushRaw(numPixels)
+
+	return out
+}
@@ -0,0 +1,166 @@
+package tga
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+//
+// testImage returns a 200x3 image; the first row is one long run of a colour (longer than an RLE packet can
+// hold), the second has short runs, and the last is a different colour in every pixel
+//
+func testImage(alpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 200, 3))
+
+	for x := 0; x < 200; x++ {
+		a := uint8(255)
+		if alpha {
+			a = uint8(x)
+		}
+
+		img.SetNRGBA(x, 0, color.NRGBA{10, 20, 30, 255})
+		img.SetNRGBA(x, 1, color.NRGBA{uint8(x / 3 * 7), 0, 0, 255})
+		img.SetNRGBA(x, 2, color.NRGBA{uint8(x), uint8(255 - x), uint8(x * 3), a})
+	}
+
+	return img
+}
+
+//
+// checkSame fails the test unless got has exactly the same (non premultiplied) pixels as want
+//
+func checkSame(t *testing.T, want, got image.Image) {
+	t.Helper()
+
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("got bounds %v, want %v", got.Bounds(), want.Bounds())
+	}
+
+	bounds := want.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if c1, c2 := color.NRGBAModel.Convert(want.At(x, y)), color.NRGBAModel.Convert(got.At(x, y)); c1 != c2 {
+				t.Fatalf("pixel %v,%v: got %v, want %v", x, y, c2, c1)
+			}
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		alpha bool
+		rle   bool
+	}{
+		{"opaque", false, false},
+		{"alpha", true, false},
+		{"opaque rle", false, true},
+		{"alpha rle", true, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			img := testImage(test.alpha)
+			buffer := &bytes.Buffer{}
+			if err := Encode(buffer, img, &Options{RLE: test.rle}); err != nil {
+				t.Fatal(err)
+			}
+
+			uncompressed := headerSize + 200*3*4
+			if test.rle && buffer.Len() >= uncompressed {
+				t.Errorf("the rle tga is %v bytes, which is no smaller than uncompressed (%v bytes)", buffer.Len(), uncompressed)
+			} else if !test.rle && buffer.Len() != uncompressed {
+				t.Errorf("the tga is %v bytes, want %v", buffer.Len(), uncompressed)
+			}
+
+			config, err := DecodeConfig(bytes.NewReader(buffer.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Width != 200 || config.Height != 3 {
+				t.Errorf("got config %vx%v, want 200x3", config.Width, config.Height)
+			}
+
+			decoded, err := Decode(buffer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkSame(t, img, decoded)
+		})
+	}
+}
+
+func TestDecodeBottomUp(t *testing.T) {
+	img := testImage(true)
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Encode writes top down tgas; clear the descriptor's top to bottom bit and put the rows the other way round
+	data := buffer.Bytes()
+	bottomUp := append([]byte{}, data[:headerSize]...)
+	bottomUp[17] &^= descriptorTopBottom
+	for row := 2; row >= 0; row-- {
+		bottomUp = append(bottomUp, data[headerSize+row*800:headerSize+(row+1)*800]...)
+	}
+
+	decoded, err := Decode(bytes.NewReader(bottomUp))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkSame(t, img, decoded)
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	for _, rle := range []bool{false, true} {
+		buffer := &bytes.Buffer{}
+		if err := Encode(buffer, testImage(true), &Options{RLE: rle}); err != nil {
+			t.Fatal(err)
+		}
+
+		data := buffer.Bytes()
+		for n := 0; n < len(data); n++ {
+			if _, err := Decode(bytes.NewReader(data[:n])); err == nil {
+				t.Errorf("rle %v: expected an error decoding the first %v of %v bytes", rle, n, len(data))
+			}
+		}
+	}
+}
+
+func TestDecodeBadHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(h []byte)
+	}{
+		{"too big", func(h []byte) {
+			binary.LittleEndian.PutUint16(h[12:], 0xFFFF)
+			binary.LittleEndian.PutUint16(h[14:], 0xFFFF)
+		}},
+		{"no width", func(h []byte) { binary.LittleEndian.PutUint16(h[12:], 0) }},
+		{"no height", func(h []byte) { binary.LittleEndian.PutUint16(h[14:], 0) }},
+		{"bad colour map type", func(h []byte) { h[1] = 2 }},
+		{"bad image type", func(h []byte) { h[2] = 5 }},
+		{"bad pixel depth", func(h []byte) { h[16] = 12 }},
+	}
+
+	for _, test := range tests {
+		buffer := &bytes.Buffer{}
+		if err := Encode(buffer, testImage(false), nil); err != nil {
+			t.Fatal(err)
+		}
+
+		data := buffer.Bytes()
+		test.change(data)
+
+		if _, err := DecodeConfig(bytes.NewReader(data)); err != ErrFormat {
+			t.Errorf("%v: DecodeConfig returned %v, want %v", test.name, err, ErrFormat)
+		}
+		if _, err := Decode(bytes.NewReader(data)); err != ErrFormat {
+			t.Errorf("%v: Decode returned %v, want %v", test.name, err, ErrFormat)
+		}
+	}
+}
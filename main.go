@@ -1,6 +1,8 @@
 package main
 
 import "fmt"
+import "image/color"
+import "image/draw"
 import "./monkey"
 import "./mods"
 import "./util"
@@ -10,6 +12,16 @@ import "runtime"
 import "strings"
 import "os"
 
+//
+// The formats (extensions) each mod's output is saved as; add more (eg. ".jpg", ".gif", ".qoi") to get those too...
+//
+var outputFormats = []string{".png"}
+
+//
+// ... and the options they are saved with
+//
+var outputOptions = util.SaveOptions{JPEGQuality: 90}
+
 //
 // Demo program to show the usage of the functions and some of the mods provided...
 //
@@ -34,6 +46,11 @@ func main() {
 		monkey := monkey.LoadImageFromFile(filepath.Join(sourceDir, sourceFile))
 		destDir := filepath.Join(autogeneratedDir, sourceFile)
 
+		fmt.Println("Format:", monkey.Format())
+		if exif, ok := monkey.EXIF(); ok {
+			fmt.Printf("EXIF: %v %v, taken %v, orientation %v\n", exif.Make, exif.Model, exif.CaptureTime, exif.Orientation)
+		}
+
 		runMod(modSwapRGBtoGBR, destDir, monkey.ImageMatrix())
 		runMod(modGreyscaleAverageWithTranslusence, destDir, monkey.ImageMatrix())
 		runMod(modBlur, destDir, monkey.ImageMatrix(), 8)
@@ -42,13 +59,106 @@ func main() {
 		runMod(modAverageBlur, destDir, monkey.ImageMatrix())
 		runMod(modApplyConvolutionWithSampleFunction, destDir, monkey.ImageMatrix())
 		runMod(modApplyFunctionToEveryPixelExample, destDir, monkey.ImageMatrix())
+		runMod(modMapExample, destDir, monkey.ImageMatrix())
+		runMod(modMapExampleInCentre, destDir, monkey.ImageMatrix())
+		runMod(modMapExampleWithMask, destDir, monkey.ImageMatrix())
+		runMod(modFocusBlur, destDir, monkey.ImageMatrix(), 4.0)
+		runMod(modColourSplash, destDir, monkey.ImageMatrix(), color.RGBA{255, 0, 0, 255}, 120.0)
+		runMod(modEdgeDetectTriangle, destDir, monkey.ImageMatrix())
+		runMod(modSharpenHighlights, destDir, monkey.ImageMatrix())
 		runMod(modSharpen, destDir, monkey.ImageMatrix())
 		runMod(modEdgeDetect, destDir, monkey.ImageMatrix())
 		runMod(modEmboss, destDir, monkey.ImageMatrix())
 		runMod(modIdentity, destDir, monkey.ImageMatrix())
 		runMod(modPlayOne, destDir, monkey.ImageMatrix())
 		runMod(modPlayTwo, destDir, monkey.ImageMatrix())
-		runMod(modSeamCarveHorizontal, destDir, monkey.ImageMatrix())
+		runSeamCarveAnimation("SeamCarveHorizontal", mods.SeamCarveHorizontalAnimation, destDir, monkey.ImageMatrix(), 20)
+		runSeamCarveAnimation("SeamCarveVertical", mods.SeamCarveVerticalAnimation, destDir, monkey.ImageMatrix(), 20)
+		runMod(modSeamCarveVersusResize, destDir, monkey.ImageMatrix(), 20)
+		runMod(modResizeNearestNeighbour, destDir, monkey.ImageMatrix(), 50)
+		runMod(modResizeBilinear, destDir, monkey.ImageMatrix(), 50)
+		runMod(modResizeCatmullRom, destDir, monkey.ImageMatrix(), 50)
+		runMod(modResizeMitchell, destDir, monkey.ImageMatrix(), 50)
+		runMod(modResizeLanczos3, destDir, monkey.ImageMatrix(), 50)
+		runMod(modResizeToFit, destDir, monkey.ImageMatrix(), 200, 200)
+		runMod(modResizeToFill, destDir, monkey.ImageMatrix(), 200, 200)
+		runMod(modFlipHorizontal, destDir, monkey.ImageMatrix())
+		runMod(modFlipVertical, destDir, monkey.ImageMatrix())
+		runMod(modTranspose, destDir, monkey.ImageMatrix())
+		runMod(modRotate90, destDir, monkey.ImageMatrix())
+		runMod(modRotate180, destDir, monkey.ImageMatrix())
+		runMod(modRotate270, destDir, monkey.ImageMatrix())
+		runMod(modRotateExpand, destDir, monkey.ImageMatrix(), 30.0)
+		runMod(modRotateCrop, destDir, monkey.ImageMatrix(), 30.0)
+		runMod(modAffineTransform, destDir, monkey.ImageMatrix())
+		runMod(modPerspectiveTilt, destDir, monkey.ImageMatrix())
+		runMod(modDeskew, destDir, mods.PerspectiveTilt(monkey.ImageMatrix()))
+		runMod(modCropCentre, destDir, monkey.ImageMatrix(), 50)
+		runMod(modPadWithColour, destDir, monkey.ImageMatrix(), 20)
+		runMod(modPadWithEdge, destDir, monkey.ImageMatrix(), 20)
+		runMod(modPadWithMirror, destDir, monkey.ImageMatrix(), 50)
+		runMod(modCanvasResize, destDir, monkey.ImageMatrix(), 300, 300)
+		runMod(modEmbossBlendedWithOriginal, destDir, monkey.ImageMatrix(), 0.4)
+		runMod(modWatermark, destDir, monkey.ImageMatrix(), 0.5)
+		runMod(modMultiplyWithMirror, destDir, monkey.ImageMatrix())
+		runMod(modScreenWithMirror, destDir, monkey.ImageMatrix())
+		runMod(modOverlayWithMirror, destDir, monkey.ImageMatrix())
+		runMod(modDifferenceWithMirror, destDir, monkey.ImageMatrix())
+		runMod(modHueWithMirror, destDir, monkey.ImageMatrix())
+		runMod(modXorWithMirror, destDir, monkey.ImageMatrix())
+		runMod(modErode, destDir, monkey.ImageMatrix(), 3)
+		runMod(modDilate, destDir, monkey.ImageMatrix(), 3)
+		runMod(modMorphologicalOpen, destDir, monkey.ImageMatrix(), 3)
+		runMod(modMorphologicalClose, destDir, monkey.ImageMatrix(), 3)
+		runMod(modMorphologicalGradient, destDir, monkey.ImageMatrix(), 1)
+		runMod(modTopHat, destDir, monkey.ImageMatrix(), 3)
+		runMod(modBlackHat, destDir, monkey.ImageMatrix(), 3)
+		runMod(modThreshold, destDir, monkey.ImageMatrix(), uint8(127))
+		runMod(modThresholdOtsu, destDir, monkey.ImageMatrix())
+		runMod(modThresholdOtsuCleaned, destDir, monkey.ImageMatrix())
+		runMod(modThresholdTriangle, destDir, monkey.ImageMatrix())
+		runMod(modAdaptiveThresholdMean, destDir, monkey.ImageMatrix(), 7, 5.0)
+		runMod(modAdaptiveThresholdGaussian, destDir, monkey.ImageMatrix(), 7, 5.0)
+		runMod(modSobelGradient, destDir, monkey.ImageMatrix())
+		runMod(modScharrGradient, destDir, monkey.ImageMatrix())
+		runMod(modPrewittGradient, destDir, monkey.ImageMatrix())
+		runMod(modCanny, destDir, monkey.ImageMatrix(), 1.4, 100.0, 250.0)
+		runMod(modMedian, destDir, monkey.ImageMatrix(), 3)
+		runMod(modBilateral, destDir, monkey.ImageMatrix(), 3.0, 30.0)
+		runMod(modKuwahara, destDir, monkey.ImageMatrix(), 4)
+		runMod(modGuidedFilter, destDir, monkey.ImageMatrix(), 4, 0.02)
+		runMod(modNonLocalMeans, destDir, monkey.ImageMatrix(), 1, 5, 10.0)
+		runMod(modUnsharpMask, destDir, monkey.ImageMatrix(), 2.0, 0.8, uint8(4), false)
+		runMod(modUnsharpMaskLuminance, destDir, monkey.ImageMatrix(), 2.0, 0.8, uint8(4), true)
+		runMod(modHighPass, destDir, monkey.ImageMatrix(), 3.0)
+		runMod(modHighPassSharpen, destDir, monkey.ImageMatrix(), 3.0, 0.8, true)
+		runMod(modQuantiseMedianCut, destDir, monkey.ImageMatrix(), 16)
+		runMod(modQuantiseOctree, destDir, monkey.ImageMatrix(), 16)
+		runMod(modQuantiseKMeans, destDir, monkey.ImageMatrix(), 16)
+
+		runMod(modDitherFloydSteinberg, destDir, monkey.ImageMatrix(), false)
+		runMod(modDitherFloydSteinbergSerpentine, destDir, monkey.ImageMatrix(), true)
+		runMod(modDitherAtkinson, destDir, monkey.ImageMatrix(), true)
+		runMod(modDitherJarvisJudiceNinke, destDir, monkey.ImageMatrix(), true)
+		runMod(modDitherSierra, destDir, monkey.ImageMatrix(), true)
+		runMod(modDitherBayer2, destDir, monkey.ImageMatrix(), 2)
+		runMod(modDitherBayer4, destDir, monkey.ImageMatrix(), 4)
+		runMod(modDitherBayer8, destDir, monkey.ImageMatrix(), 8)
+		runMod(modDitherToPalette, destDir, monkey.ImageMatrix(), 16)
+
+		savePaletted(destDir, monkey.ImageMatrix(), 64)
+		saveWithMetadata(destDir, monkey)
+		saveAsNetpbm(destDir, monkey.ImageMatrix())
+		saveAsBMPTGAQOI(destDir, monkey.ImageMatrix())
+
+		// gifs can be animated, so run some of the mods over every frame as well...
+		if strings.ToLower(filepath.Ext(sourceFile)) == ".gif" {
+			animation := monkey.Animation()
+			runAnimationMod(modSwapRGBtoGBR, destDir, animation)
+			runAnimationMod(modEmboss, destDir, animation)
+			runAnimationMod(modRotate90, destDir, animation)
+			runAnimationMod(modResizeLanczos3, destDir, animation, 50)
+		}
 	}
 }
 
@@ -75,24 +185,187 @@ func runMod(modfunc func(monkey.ImageMatrix, ...interface{}) monkey.ImageMatrix,
 	newImageMatrix := modfunc(imageMatrix, vars...)
 	newImage := monkey.ImageMatrixToImage(newImageMatrix)
 
-	// Save as PNG
-	destImage := filepath.Join(destDir, modName+".png")
+	// Save in each of the output formats (the format comes from the extension)...
+	for _, ext := range outputFormats {
+		destImage := filepath.Join(destDir, modName+ext)
+		fmt.Println("Out:", destImage)
+		err = util.Save(destImage, newImage, &outputOptions)
+		util.CheckError(err)
+	}
+
+	fmt.Println()
+}
+
+// call the correct mod function on every frame of the animation, and save the result as an animated gif...
+func runAnimationMod(modfunc func(monkey.ImageMatrix, ...interface{}) monkey.ImageMatrix,
+	destDir string, animation monkey.Animation, vars ...interface{}) {
+	modName := getFunctionName(modfunc)
+	modName = strings.Replace(modName, "main.mod", "", 1)
+
+	fmt.Printf("Running mod %v on %v frames\n", modName, len(animation.Frames))
+
+	err := os.MkdirAll(destDir, os.ModePerm)
+	util.CheckError(err)
+
+	newAnimation := animation.ApplyToEveryFrame(func(imageMatrix monkey.ImageMatrix) monkey.ImageMatrix {
+		return modfunc(imageMatrix, vars...)
+	})
+
+	// (nearest colour rather than dithering, so the frames don't shimmer)
+	destImage := filepath.Join(destDir, "Animated"+modName+".gif")
+	fmt.Println("Out:", destImage)
+	util.SaveGIFToFile(destImage, newAnimation.GIF(256, monkey.Quantizer{Method: monkey.MedianCut}, draw.Src))
+
+	fmt.Println()
+}
+
+// run the seam carver, saving a frame for every seam as an animated gif and as a numbered sequence of pngs...
+func runSeamCarveAnimation(name string, carve func(monkey.ImageMatrix, int) monkey.Animation,
+	destDir string, imageMatrix monkey.ImageMatrix, percentage int) {
+	// carve percentage% of the smaller side (but no more than 40 seams, so the big samples don't take forever)
+	seams := imageMatrix.GetWidth()
+	if imageMatrix.GetHeight() < seams {
+		seams = imageMatrix.GetHeight()
+	}
+
+	seams = seams * percentage / 100
+	if seams > 40 {
+		seams = 40
+	}
+
+	fmt.Printf("Running %v for %v seams\n", name, seams)
+
+	framesDir := filepath.Join(destDir, name)
+	err := os.MkdirAll(framesDir, os.ModePerm)
+	util.CheckError(err)
+
+	animation := carve(imageMatrix, seams)
+
+	destImage := filepath.Join(destDir, name+".gif")
+	fmt.Println("Out:", destImage)
+	util.SaveGIFToFile(destImage, animation.GIF(256, monkey.Quantizer{Method: monkey.MedianCut}, draw.Src))
+
+	for i, frame := range animation.Frames {
+		destImage = filepath.Join(framesDir, fmt.Sprintf("%04d.png", i+1))
+		util.SaveImageToFileAsPNG(destImage, monkey.ImageMatrixToImage(frame.Matrix))
+	}
+	fmt.Printf("Out: %v (%v frames)\n", filepath.Join(framesDir, "*.png"), len(animation.Frames))
+
+	fmt.Println()
+}
+
+// save the image in each of the Netpbm formats...
+func saveAsNetpbm(destDir string, imageMatrix monkey.ImageMatrix) {
+	err := os.MkdirAll(destDir, os.ModePerm)
+	util.CheckError(err)
+
+	image := monkey.ImageMatrixToImage(imageMatrix)
+	fmt.Println("Saving as Netpbm")
+
+	destImage := filepath.Join(destDir, "Netpbm.pbm")
+	fmt.Println("Out:", destImage)
+	util.SaveImageToFileAsPBM(destImage, image)
+
+	destImage = filepath.Join(destDir, "Netpbm.pgm")
+	fmt.Println("Out:", destImage)
+	util.SaveImageToFileAsPGM(destImage, image)
+
+	destImage = filepath.Join(destDir, "Netpbm.ppm")
+	fmt.Println("Out:", destImage)
+	util.SaveImageToFileAsPPM(destImage, image)
+
+	destImage = filepath.Join(destDir, "Netpbm.pam")
+	fmt.Println("Out:", destImage)
+	util.SaveImageToFileAsPAM(destImage, image)
+
+	fmt.Println()
+}
+
+// save the image as a bmp, a tga (uncompressed and RLE) and a qoi...
+func saveAsBMPTGAQOI(destDir string, imageMatrix monkey.ImageMatrix) {
+	err := os.MkdirAll(destDir, os.ModePerm)
+	util.CheckError(err)
+
+	image := monkey.ImageMatrixToImage(imageMatrix)
+	fmt.Println("Saving as BMP, TGA and QOI")
+
+	destImage := filepath.Join(destDir, "Image.bmp")
+	fmt.Println("Out:", destImage)
+	util.SaveImageToFileAsBMP(destImage, image)
+
+	destImage = filepath.Join(destDir, "Image.tga")
+	fmt.Println("Out:", destImage)
+	util.SaveImageToFileAsTGA(destImage, image, false)
+
+	destImage = filepath.Join(destDir, "ImageRLE.tga")
+	fmt.Println("Out:", destImage)
+	util.SaveImageToFileAsTGA(destImage, image, true)
+
+	destImage = filepath.Join(destDir, "Image.qoi")
+	fmt.Println("Out:", destImage)
+	util.SaveImageToFileAsQOI(destImage, image)
+
+	fmt.Println()
+}
+
+// save the image with it's metadata (EXIF, XMP, ICC profile, text) kept, and again with it stripped...
+func saveWithMetadata(destDir string, source *monkey.Monkey) {
+	err := os.MkdirAll(destDir, os.ModePerm)
+	util.CheckError(err)
+
+	image := monkey.ImageMatrixToImage(source.ImageMatrix())
+	fmt.Println("Saving with metadata")
+
+	destImage := filepath.Join(destDir, "WithMetadata.jpg")
+	fmt.Println("Out:", destImage)
+	util.SaveImageToFileAsJPGWithMetadata(destImage, image, source.Metadata())
+
+	destImage = filepath.Join(destDir, "WithMetadata.png")
+	fmt.Println("Out:", destImage)
+	util.SaveImageToFileAsPNGWithMetadata(destImage, image, source.Metadata())
+
+	source.SetStripMetadata(true)
+	destImage = filepath.Join(destDir, "StrippedMetadata.jpg")
 	fmt.Println("Out:", destImage)
-	util.SaveImageToFileAsPNG(destImage, newImage)
-
-	// // Save as JPG
-	// destImage = filepath.Join(destDir, modName+".jpg")
-	// fmt.Println("Out:", destImage)
-	// util.SaveImageToFileAsJPG(destImage, newImage)
-	//
-	// // Save as GIF
-	// destImage = filepath.Join(destDir, modName+".gif")
-	// fmt.Println("Out:", destImage)
-	// util.SaveImageToFileAsGIF(destImage, newImage)
+	util.SaveImageToFileAsJPGWithMetadata(destImage, image, source.Metadata())
+	source.SetStripMetadata(false)
 
 	fmt.Println()
 }
 
+// save the image as a gif and an indexed png with a palette from each of the palette methods...
+func savePaletted(destDir string, imageMatrix monkey.ImageMatrix, numColours int) {
+	err := os.MkdirAll(destDir, os.ModePerm)
+	util.CheckError(err)
+
+	image := monkey.ImageMatrixToImage(imageMatrix)
+
+	for _, method := range []monkey.PaletteMethod{monkey.MedianCut, monkey.Octree, monkey.KMeans} {
+		fmt.Printf("Saving with a %v palette of %v colours\n", method, numColours)
+		quantizer := monkey.Quantizer{Method: method}
+
+		// (once with the nearest colour, and once with serpentine Floyd-Steinberg dithering)
+		suffixes := []string{"", "Dithered"}
+		drawers := []draw.Drawer{draw.Src, monkey.Ditherer{Diffusion: monkey.FloydSteinberg, Serpentine: true}}
+
+		for i, drawer := range drawers {
+			name := "Palette" + method.String() + suffixes[i]
+
+			// Save as GIF
+			destImage := filepath.Join(destDir, name+".gif")
+			fmt.Println("Out:", destImage)
+			util.SaveImageToFileAsGIFWithPalette(destImage, image, numColours, quantizer, drawer)
+
+			// Save as indexed PNG
+			destImage = filepath.Join(destDir, name+".png")
+			fmt.Println("Out:", destImage)
+			util.SaveImageToFileAsIndexedPNG(destImage, image, numColours, quantizer, drawer)
+		}
+
+		fmt.Println()
+	}
+}
+
 /////////////////////////////////////////////////////////////////////////////////////////////////////
 // mod: SwapRGBtoGBR
 //
@@ -159,6 +432,65 @@ func modApplyFunctionToEveryPixelExample(imageMatrix monkey.ImageMatrix, vars ..
 	return newImageMatrix
 }
 
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modMapExample
+//
+func modMapExample(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.MapExample(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modMapExampleInCentre
+//
+func modMapExampleInCentre(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.MapExampleInCentre(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modMapExampleWithMask
+//
+func modMapExampleWithMask(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.MapExampleWithMask(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modFocusBlur
+//
+func modFocusBlur(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	sigma := vars[0].(float64)
+	newImageMatrix := mods.FocusBlur(imageMatrix, sigma)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modColourSplash
+//
+func modColourSplash(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	colour := vars[0].(color.RGBA)
+	tolerance := vars[1].(float64)
+	newImageMatrix := mods.ColourSplash(imageMatrix, colour, tolerance)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modEdgeDetectTriangle
+//
+func modEdgeDetectTriangle(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.EdgeDetectTriangle(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modSharpenHighlights
+//
+func modSharpenHighlights(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.SharpenHighlights(imageMatrix)
+	return newImageMatrix
+}
+
 /////////////////////////////////////////////////////////////////////////////////////////////////////
 // mod: modApplyFunctionToEveryPixelExample
 //
@@ -208,9 +540,624 @@ func modPlayTwo(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.Imag
 }
 
 /////////////////////////////////////////////////////////////////////////////////////////////////////
-// mod: modSeamCarveHorizontal
+// mod: modSeamCarveVersusResize
+//
+func modSeamCarveVersusResize(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	percentage := vars[0].(int)
+	newImageMatrix := mods.SeamCarveVersusResize(imageMatrix, percentage)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modResizeNearestNeighbour
+//
+func modResizeNearestNeighbour(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	percentage := vars[0].(int)
+	newImageMatrix := mods.Resize(imageMatrix, percentage, monkey.NearestNeighbour)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modResizeBilinear
+//
+func modResizeBilinear(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	percentage := vars[0].(int)
+	newImageMatrix := mods.Resize(imageMatrix, percentage, monkey.Bilinear)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modResizeCatmullRom
+//
+func modResizeCatmullRom(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	percentage := vars[0].(int)
+	newImageMatrix := mods.Resize(imageMatrix, percentage, monkey.CatmullRom)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modResizeMitchell
+//
+func modResizeMitchell(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	percentage := vars[0].(int)
+	newImageMatrix := mods.Resize(imageMatrix, percentage, monkey.Mitchell)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modResizeLanczos3
+//
+func modResizeLanczos3(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	percentage := vars[0].(int)
+	newImageMatrix := mods.Resize(imageMatrix, percentage, monkey.Lanczos3)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modResizeToFit
+//
+func modResizeToFit(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	width := vars[0].(int)
+	height := vars[1].(int)
+	newImageMatrix := mods.ResizeToFit(imageMatrix, width, height)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modResizeToFill
+//
+func modResizeToFill(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	width := vars[0].(int)
+	height := vars[1].(int)
+	newImageMatrix := mods.ResizeToFill(imageMatrix, width, height)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modFlipHorizontal
+//
+func modFlipHorizontal(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.FlipHorizontal(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modFlipVertical
+//
+func modFlipVertical(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.FlipVertical(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modTranspose
+//
+func modTranspose(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.Transpose(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modRotate90
+//
+func modRotate90(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.Rotate90(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modRotate180
+//
+func modRotate180(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.Rotate180(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modRotate270
+//
+func modRotate270(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.Rotate270(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modRotateExpand
+//
+func modRotateExpand(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	degrees := vars[0].(float64)
+	newImageMatrix := mods.Rotate(imageMatrix, degrees, true)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modRotateCrop
+//
+func modRotateCrop(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	degrees := vars[0].(float64)
+	newImageMatrix := mods.Rotate(imageMatrix, degrees, false)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modAffineTransform
+//
+func modAffineTransform(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.AffineTransform(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modPerspectiveTilt
+//
+func modPerspectiveTilt(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.PerspectiveTilt(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modDeskew (we run this on the output of PerspectiveTilt, so it should put the image back to how it
+// started, give or take a little softness from the interpolation)
+//
+func modDeskew(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	width := float64(imageMatrix.GetWidth())
+	height := float64(imageMatrix.GetHeight())
+	corners := [4]monkey.FloatPoint{{X: width * 0.15, Y: 0}, {X: width * 0.85, Y: 0}, {X: width, Y: height}, {X: 0, Y: height}}
+
+	newImageMatrix := mods.Deskew(imageMatrix, corners)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modCropCentre
+//
+func modCropCentre(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	percentage := vars[0].(int)
+	newImageMatrix := mods.CropCentre(imageMatrix, percentage)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modPadWithColour
+//
+func modPadWithColour(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	size := vars[0].(int)
+	newImageMatrix := mods.PadWithColour(imageMatrix, size, color.RGBA{255, 0, 255, 255})
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modPadWithEdge
+//
+func modPadWithEdge(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	size := vars[0].(int)
+	newImageMatrix := mods.PadWithEdge(imageMatrix, size)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modPadWithMirror
+//
+func modPadWithMirror(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	size := vars[0].(int)
+	newImageMatrix := mods.PadWithMirror(imageMatrix, size)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modCanvasResize
+//
+func modCanvasResize(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	width := vars[0].(int)
+	height := vars[1].(int)
+	newImageMatrix := mods.CanvasResize(imageMatrix, width, height, monkey.AnchorCentre)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modEmbossBlendedWithOriginal
+//
+func modEmbossBlendedWithOriginal(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	opacity := vars[0].(float64)
+	newImageMatrix := mods.BlendWithOriginal(imageMatrix, mods.Emboss(imageMatrix), opacity)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modWatermark (uses a quarter size, upside down, copy of the image as the watermark)
+//
+func modWatermark(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	opacity := vars[0].(float64)
+	mark := imageMatrix.Resize(imageMatrix.GetWidth()/4, 0, monkey.Lanczos3).Rotate180()
+	newImageMatrix := mods.Watermark(imageMatrix, mark, opacity)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modMultiplyWithMirror
+//
+func modMultiplyWithMirror(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.BlendWithMirror(imageMatrix, monkey.BlendMultiply)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modScreenWithMirror
+//
+func modScreenWithMirror(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.BlendWithMirror(imageMatrix, monkey.BlendScreen)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modOverlayWithMirror
+//
+func modOverlayWithMirror(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.BlendWithMirror(imageMatrix, monkey.BlendOverlay)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modDifferenceWithMirror
+//
+func modDifferenceWithMirror(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.BlendWithMirror(imageMatrix, monkey.BlendDifference)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modHueWithMirror
+//
+func modHueWithMirror(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.BlendWithMirror(imageMatrix, monkey.BlendHue)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modXorWithMirror
+//
+func modXorWithMirror(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.BlendWithMirror(imageMatrix, monkey.CompositeXor)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modErode
+//
+func modErode(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(int)
+	newImageMatrix := mods.Erode(imageMatrix, radius)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modDilate
+//
+func modDilate(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(int)
+	newImageMatrix := mods.Dilate(imageMatrix, radius)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modMorphologicalOpen
+//
+func modMorphologicalOpen(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(int)
+	newImageMatrix := mods.MorphologicalOpen(imageMatrix, radius)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modMorphologicalClose
+//
+func modMorphologicalClose(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(int)
+	newImageMatrix := mods.MorphologicalClose(imageMatrix, radius)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modMorphologicalGradient
+//
+func modMorphologicalGradient(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(int)
+	newImageMatrix := mods.MorphologicalGradient(imageMatrix, radius)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modTopHat
+//
+func modTopHat(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(int)
+	newImageMatrix := mods.TopHat(imageMatrix, radius)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modBlackHat
+//
+func modBlackHat(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(int)
+	newImageMatrix := mods.BlackHat(imageMatrix, radius)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modThreshold
+//
+func modThreshold(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	level := vars[0].(uint8)
+	newImageMatrix := mods.Threshold(imageMatrix, level)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modThresholdOtsu
+//
+func modThresholdOtsu(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.ThresholdOtsu(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modThresholdOtsuCleaned
+//
+func modThresholdOtsuCleaned(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.ThresholdOtsuCleaned(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modThresholdTriangle
+//
+func modThresholdTriangle(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.ThresholdTriangle(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modAdaptiveThresholdMean
+//
+func modAdaptiveThresholdMean(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(int)
+	c := vars[1].(float64)
+	newImageMatrix := mods.AdaptiveThresholdMean(imageMatrix, radius, c)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modAdaptiveThresholdGaussian
+//
+func modAdaptiveThresholdGaussian(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(int)
+	c := vars[1].(float64)
+	newImageMatrix := mods.AdaptiveThresholdGaussian(imageMatrix, radius, c)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modSobelGradient
+//
+func modSobelGradient(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.SobelGradient(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modScharrGradient
+//
+func modScharrGradient(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.ScharrGradient(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modPrewittGradient
+//
+func modPrewittGradient(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	newImageMatrix := mods.PrewittGradient(imageMatrix)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modCanny
+//
+func modCanny(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	sigma := vars[0].(float64)
+	low := vars[1].(float64)
+	high := vars[2].(float64)
+	newImageMatrix := mods.Canny(imageMatrix, sigma, low, high)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modMedian
+//
+func modMedian(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(int)
+	newImageMatrix := mods.Median(imageMatrix, radius)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modBilateral
+//
+func modBilateral(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	spatialSigma := vars[0].(float64)
+	rangeSigma := vars[1].(float64)
+	newImageMatrix := mods.Bilateral(imageMatrix, spatialSigma, rangeSigma)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modKuwahara
+//
+func modKuwahara(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(int)
+	newImageMatrix := mods.Kuwahara(imageMatrix, radius)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modGuidedFilter
+//
+func modGuidedFilter(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(int)
+	epsilon := vars[1].(float64)
+	newImageMatrix := mods.GuidedFilter(imageMatrix, radius, epsilon)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modNonLocalMeans
+//
+func modNonLocalMeans(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	patchRadius := vars[0].(int)
+	searchRadius := vars[1].(int)
+	h := vars[2].(float64)
+	newImageMatrix := mods.NonLocalMeans(imageMatrix, patchRadius, searchRadius, h)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modUnsharpMask
+//
+func modUnsharpMask(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(float64)
+	amount := vars[1].(float64)
+	threshold := vars[2].(uint8)
+	luminanceOnly := vars[3].(bool)
+	newImageMatrix := mods.UnsharpMask(imageMatrix, radius, amount, threshold, luminanceOnly)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modUnsharpMaskLuminance (the same as modUnsharpMask, it's just a different name for the output file)
+//
+func modUnsharpMaskLuminance(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	return modUnsharpMask(imageMatrix, vars...)
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modHighPass
+//
+func modHighPass(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(float64)
+	newImageMatrix := mods.HighPass(imageMatrix, radius)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modHighPassSharpen
+//
+func modHighPassSharpen(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	radius := vars[0].(float64)
+	opacity := vars[1].(float64)
+	luminanceOnly := vars[2].(bool)
+	newImageMatrix := mods.HighPassSharpen(imageMatrix, radius, opacity, luminanceOnly)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modQuantiseMedianCut
+//
+func modQuantiseMedianCut(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	numColours := vars[0].(int)
+	newImageMatrix := mods.Quantise(imageMatrix, monkey.MedianCut, numColours)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modQuantiseOctree
+//
+func modQuantiseOctree(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	numColours := vars[0].(int)
+	newImageMatrix := mods.Quantise(imageMatrix, monkey.Octree, numColours)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modQuantiseKMeans
+//
+func modQuantiseKMeans(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	numColours := vars[0].(int)
+	newImageMatrix := mods.Quantise(imageMatrix, monkey.KMeans, numColours)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modDitherFloydSteinberg
+//
+func modDitherFloydSteinberg(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	serpentine := vars[0].(bool)
+	newImageMatrix := mods.DitherOneBit(imageMatrix, monkey.FloydSteinberg, serpentine)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modDitherFloydSteinbergSerpentine (the same as modDitherFloydSteinberg, it's just a different name for the output file)
+//
+func modDitherFloydSteinbergSerpentine(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	return modDitherFloydSteinberg(imageMatrix, vars...)
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modDitherAtkinson
+//
+func modDitherAtkinson(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	serpentine := vars[0].(bool)
+	newImageMatrix := mods.DitherOneBit(imageMatrix, monkey.Atkinson, serpentine)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modDitherJarvisJudiceNinke
+//
+func modDitherJarvisJudiceNinke(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	serpentine := vars[0].(bool)
+	newImageMatrix := mods.DitherOneBit(imageMatrix, monkey.JarvisJudiceNinke, serpentine)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modDitherSierra
+//
+func modDitherSierra(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	serpentine := vars[0].(bool)
+	newImageMatrix := mods.DitherOneBit(imageMatrix, monkey.Sierra, serpentine)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modDitherBayer2
+//
+func modDitherBayer2(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	size := vars[0].(int)
+	newImageMatrix := mods.DitherOneBitOrdered(imageMatrix, size)
+	return newImageMatrix
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modDitherBayer4 (the same as modDitherBayer2, it's just a different name for the output file)
+//
+func modDitherBayer4(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	return modDitherBayer2(imageMatrix, vars...)
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modDitherBayer8 (the same as modDitherBayer2, it's just a different name for the output file)
+//
+func modDitherBayer8(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	return modDitherBayer2(imageMatrix, vars...)
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+// mod: modDitherToPalette
 //
-func modSeamCarveHorizontal(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
-	newImageMatrix := mods.SeamCarveHorizontal(imageMatrix)
+func modDitherToPalette(imageMatrix monkey.ImageMatrix, vars ...interface{}) monkey.ImageMatrix {
+	numColours := vars[0].(int)
+	newImageMatrix := mods.DitherToPalette(imageMatrix, numColours)
 	return newImageMatrix
 }
@@ -0,0 +1,14 @@
+package mods
+
+import "../monkey"
+import "image/color"
+
+//
+// AffineTransform is an example of a general affine warp... it shears the image horizontally, squashes it a
+// little vertically and then rotates it slightly. The uncovered parts of the canvas are left transparent.
+//
+func AffineTransform(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	m := monkey.RotationMatrix(-10).Multiply(monkey.ScaleMatrix(1, 0.8)).Multiply(monkey.ShearMatrix(0.3, 0))
+	newMatrix := matrix.AffineTransform(m, monkey.Bilinear, color.RGBA{})
+	return newMatrix
+}
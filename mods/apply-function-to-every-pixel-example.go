@@ -4,11 +4,11 @@ import "../monkey"
 import "image/color"
 
 //
-// ApplyFunctionToEveryPixelExample is an example of how to pass a callback function to ApplyFunctionToEveryPixelExample
+// ApplyFunctionToEveryPixelExample is an example of how to pass a callback function to ApplyFunctionToEveryPixel
 //
 func ApplyFunctionToEveryPixelExample(matrix monkey.ImageMatrix) monkey.ImageMatrix {
-	matrix.ApplyFunctionToEveryPixel(rgb2brg)
-	return matrix
+	newMatrix := matrix.ApplyFunctionToEveryPixel(rgb2brg)
+	return newMatrix
 }
 
 func rgb2brg(im monkey.ImageMatrix, x, y int) color.RGBA {
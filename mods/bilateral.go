@@ -0,0 +1,13 @@
+package mods
+
+import "../monkey"
+
+//
+// Bilateral smooths the image while keeping the edges sharp... spatialSigma is how far (in pixels) the smoothing
+// reaches, and rangeSigma is how different (in 0-255 colour units) two colours can be and still be smoothed
+// together.
+//
+func Bilateral(matrix monkey.ImageMatrix, spatialSigma, rangeSigma float64) monkey.ImageMatrix {
+	newMatrix := matrix.BilateralFilter(spatialSigma, rangeSigma)
+	return newMatrix
+}
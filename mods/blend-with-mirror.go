@@ -0,0 +1,13 @@
+package mods
+
+import "../monkey"
+import "image"
+
+//
+// BlendWithMirror blends a mirrored (left to right) copy of the image onto the image with the given blend mode
+// or operator... it's a simple way to see what each of the blend modes do.
+//
+func BlendWithMirror(matrix monkey.ImageMatrix, op monkey.CompositeOp) monkey.ImageMatrix {
+	newMatrix := monkey.Composite(matrix, matrix.FlipHorizontal(), op, image.Point{}, 1)
+	return newMatrix
+}
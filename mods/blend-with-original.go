@@ -0,0 +1,13 @@
+package mods
+
+import "../monkey"
+import "image"
+
+//
+// BlendWithOriginal mixes the output of a mod (the effect) back in with the original image... eg. an opacity
+// of 0.4 gives you 60% of the original image and 40% of the effect.
+//
+func BlendWithOriginal(original, effect monkey.ImageMatrix, opacity float64) monkey.ImageMatrix {
+	newMatrix := monkey.Composite(original, effect, monkey.CompositeOver, image.Point{}, opacity)
+	return newMatrix
+}
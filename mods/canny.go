@@ -0,0 +1,13 @@
+package mods
+
+import "../monkey"
+
+//
+// Canny finds the edges in the image with the Canny edge detector, giving clean white edges (1 pixel wide) on
+// a black background... sigma is how much the image is smoothed first, and low/high are the hysteresis
+// thresholds (in units of the Sobel gradient magnitude).
+//
+func Canny(matrix monkey.ImageMatrix, sigma, low, high float64) monkey.ImageMatrix {
+	newMatrix := matrix.Canny(sigma, low, high).ImageMatrix()
+	return newMatrix
+}
@@ -0,0 +1,13 @@
+package mods
+
+import "../monkey"
+import "image/color"
+
+//
+// CanvasResize changes the size of the canvas (leaving the image itself alone) with the image anchored at the
+// given point; any new area is left transparent...
+//
+func CanvasResize(matrix monkey.ImageMatrix, width, height int, anchor monkey.Anchor) monkey.ImageMatrix {
+	newMatrix := matrix.CanvasResize(width, height, anchor, color.RGBA{})
+	return newMatrix
+}
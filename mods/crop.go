@@ -0,0 +1,18 @@
+package mods
+
+import "../monkey"
+import "image"
+
+//
+// CropCentre keeps the middle of the image, cropping off the given percentage of the width and height (split
+// equally between both sides)...
+//
+func CropCentre(matrix monkey.ImageMatrix, percentage int) monkey.ImageMatrix {
+	width := matrix.GetWidth()
+	height := matrix.GetHeight()
+	cropX := width * percentage / 200
+	cropY := height * percentage / 200
+
+	newMatrix := matrix.Crop(image.Rect(cropX, cropY, width-cropX, height-cropY))
+	return newMatrix
+}
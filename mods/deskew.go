@@ -0,0 +1,13 @@
+package mods
+
+import "../monkey"
+
+//
+// Deskew flattens out the quad with the given corners (top left, top right, bottom right, bottom left) into a
+// rectangle, eg. to straighten up a photo of a document that was taken at an angle. The size of the new image
+// is estimated from the lengths of the sides of the quad.
+//
+func Deskew(matrix monkey.ImageMatrix, corners [4]monkey.FloatPoint) monkey.ImageMatrix {
+	newMatrix := matrix.Deskew(corners, 0, 0, monkey.CatmullRom)
+	return newMatrix
+}
@@ -0,0 +1,36 @@
+package mods
+
+import "image/color"
+import "../monkey"
+
+//
+// OneBitPalette is just black and white, for the retro 1-bit look (and e-ink displays)
+//
+var OneBitPalette = color.Palette{color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255}}
+
+//
+// DitherOneBit reduces the image to black and white with error diffusion dithering
+//
+func DitherOneBit(matrix monkey.ImageMatrix, method monkey.ErrorDiffusion, serpentine bool) monkey.ImageMatrix {
+	newMatrix := matrix.Dither(OneBitPalette, method, serpentine)
+	return newMatrix
+}
+
+//
+// DitherOneBitOrdered reduces the image to black and white with ordered dithering (size is the size of the
+// Bayer matrix; 2, 4 or 8)
+//
+func DitherOneBitOrdered(matrix monkey.ImageMatrix, size int) monkey.ImageMatrix {
+	newMatrix := matrix.OrderedDither(OneBitPalette, size)
+	return newMatrix
+}
+
+//
+// DitherToPalette reduces the image to (at most) numColours colours picked with median cut, using serpentine
+// Floyd-Steinberg dithering to hide the banding that Quantise gives on gradients
+//
+func DitherToPalette(matrix monkey.ImageMatrix, numColours int) monkey.ImageMatrix {
+	palette := matrix.Palette(monkey.MedianCut, numColours)
+	newMatrix := matrix.Dither(palette, monkey.FloydSteinberg, true)
+	return newMatrix
+}
@@ -0,0 +1,27 @@
+package mods
+
+import "../monkey"
+
+//
+// FlipHorizontal mirrors the image left to right...
+//
+func FlipHorizontal(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	newMatrix := matrix.FlipHorizontal()
+	return newMatrix
+}
+
+//
+// FlipVertical mirrors the image top to bottom...
+//
+func FlipVertical(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	newMatrix := matrix.FlipVertical()
+	return newMatrix
+}
+
+//
+// Transpose mirrors the image along it's top-left to bottom-right diagonal...
+//
+func Transpose(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	newMatrix := matrix.Transpose()
+	return newMatrix
+}
@@ -0,0 +1,28 @@
+package mods
+
+import "../monkey"
+
+//
+// SobelGradient shows the strength of the edges in the image (the magnitude of the Sobel gradient, scaled so
+// that the strongest edge is white)...
+//
+func SobelGradient(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	newMatrix := matrix.Gradient(monkey.Sobel).Magnitude.Normalised().ImageMatrix()
+	return newMatrix
+}
+
+//
+// ScharrGradient shows the strength of the edges in the image using the Scharr operator...
+//
+func ScharrGradient(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	newMatrix := matrix.Gradient(monkey.Scharr).Magnitude.Normalised().ImageMatrix()
+	return newMatrix
+}
+
+//
+// PrewittGradient shows the strength of the edges in the image using the Prewitt operator...
+//
+func PrewittGradient(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	newMatrix := matrix.Gradient(monkey.Prewitt).Magnitude.Normalised().ImageMatrix()
+	return newMatrix
+}
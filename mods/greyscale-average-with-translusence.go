@@ -8,16 +8,17 @@ import "../monkey"
 func GreyscaleAverageWithTranslusence(matrix monkey.ImageMatrix) monkey.ImageMatrix {
 	width := len(matrix)
 	height := len(matrix[0])
+	newMatrix := matrix.Clone()
 
 	for x := 0; x < width; x++ {
 		for y := 0; y < height; y++ {
-			colour := matrix[x][y]
+			colour := newMatrix[x][y]
 			average := (colour.R + colour.G + colour.B) / 3
 			colour.R, colour.G, colour.B = average, average, average
 			colour.A = colour.A / 2
-			matrix[x][y] = colour
+			newMatrix[x][y] = colour
 		}
 	}
 
-	return matrix
+	return newMatrix
 }
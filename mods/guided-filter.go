@@ -0,0 +1,12 @@
+package mods
+
+import "../monkey"
+
+//
+// GuidedFilter smooths the image, using itself as the guide so that it's edges are kept... the larger epsilon
+// (eg. 0.01 - 0.1) is, the stronger an edge has to be to survive.
+//
+func GuidedFilter(matrix monkey.ImageMatrix, radius int, epsilon float64) monkey.ImageMatrix {
+	newMatrix := matrix.GuidedFilter(matrix, radius, epsilon)
+	return newMatrix
+}
@@ -0,0 +1,19 @@
+package mods
+
+import "../monkey"
+
+//
+// HighPass shows just the fine detail of the image on a mid grey background...
+//
+func HighPass(matrix monkey.ImageMatrix, radius float64) monkey.ImageMatrix {
+	newMatrix := matrix.HighPass(radius, true)
+	return newMatrix
+}
+
+//
+// HighPassSharpen sharpens the image by overlaying it's own high pass on top of it at the given opacity (0 to 1)...
+//
+func HighPassSharpen(matrix monkey.ImageMatrix, radius, opacity float64, luminanceOnly bool) monkey.ImageMatrix {
+	newMatrix := matrix.HighPassSharpen(radius, opacity, luminanceOnly)
+	return newMatrix
+}
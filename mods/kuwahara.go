@@ -0,0 +1,11 @@
+package mods
+
+import "../monkey"
+
+//
+// Kuwahara flattens the image into patches of colour (a bit like an oil painting) while keeping the edges sharp...
+//
+func Kuwahara(matrix monkey.ImageMatrix, radius int) monkey.ImageMatrix {
+	newMatrix := matrix.KuwaharaFilter(radius)
+	return newMatrix
+}
@@ -0,0 +1,61 @@
+package mods
+
+import "../monkey"
+import "image"
+import "image/color"
+
+//
+// MapExample is an example of how to pass a callback function to Map; the callback reads the pixel's neighbours
+// (which is safe with Map, as they are never the already changed ones) and averages them, blurring the image...
+//
+func MapExample(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	newMatrix := matrix.Map(averageOfNeighbours)
+	return newMatrix
+}
+
+//
+// MapExampleInCentre is MapExample for only the middle of the image (the centre half of the width and height)...
+//
+func MapExampleInCentre(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	width := matrix.GetWidth()
+	height := matrix.GetHeight()
+	roi := image.Rect(width/4, height/4, width*3/4, height*3/4)
+
+	newMatrix := matrix.MapInRect(roi, averageOfNeighbours)
+	return newMatrix
+}
+
+//
+// MapExampleWithMask is MapExample for only the brighter pixels of the image (as picked by Otsu's method), using
+// MapThroughMask with the (hard edged) threshold as the mask...
+//
+func MapExampleWithMask(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	mask := matrix.Threshold(matrix.OtsuThreshold())
+
+	newMatrix := matrix.MapThroughMask(mask.Mask(), averageOfNeighbours)
+	return newMatrix
+}
+
+//
+// averageOfNeighbours returns the average colour of the 5x5 square of pixels around (x, y) (or as much of it as
+// is inside the image)
+//
+func averageOfNeighbours(src monkey.ImageMatrix, x, y int) color.RGBA {
+	width := src.GetWidth()
+	height := src.GetHeight()
+	var r, g, b, a, count int
+
+	for i := x - 2; i <= x+2; i++ {
+		for j := y - 2; j <= y+2; j++ {
+			if i < 0 || j < 0 || i >= width || j >= height {
+				continue
+			}
+
+			c := src[i][j]
+			r, g, b, a = r+int(c.R), g+int(c.G), b+int(c.B), a+int(c.A)
+			count++
+		}
+	}
+
+	return color.RGBA{uint8(r / count), uint8(g / count), uint8(b / count), uint8(a / count)}
+}
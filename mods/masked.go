@@ -0,0 +1,64 @@
+package mods
+
+import "../monkey"
+import "image"
+import "image/color"
+
+//
+// FocusBlur keeps an oval in the middle of the image sharp, and blurs everything around it (by sigma pixels),
+// fading smoothly between the two, like a photo taken with a shallow depth of field...
+//
+func FocusBlur(matrix monkey.ImageMatrix, sigma float64) monkey.ImageMatrix {
+	width := matrix.GetWidth()
+	height := matrix.GetHeight()
+
+	oval := image.Rect(width/6, height/6, width*5/6, height*5/6)
+	mask := monkey.EllipseMask(width, height, oval).Invert().Feather(float64(width) / 20)
+
+	newMatrix := matrix.ApplyThroughMask(mask, func(m monkey.ImageMatrix) monkey.ImageMatrix {
+		return m.GaussianBlur(sigma)
+	})
+	return newMatrix
+}
+
+//
+// ColourSplash turns everything in the image greyscale, apart from the colours close to colour...
+//
+func ColourSplash(matrix monkey.ImageMatrix, colour color.RGBA, tolerance float64) monkey.ImageMatrix {
+	mask := matrix.ColourRangeMask(colour, tolerance, tolerance/2).Invert()
+
+	newMatrix := matrix.MapThroughMask(mask, func(src monkey.ImageMatrix, x, y int) color.RGBA {
+		c := src[x][y]
+		grey := uint8(0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B) + 0.5)
+		return color.RGBA{grey, grey, grey, c.A}
+	})
+	return newMatrix
+}
+
+//
+// EdgeDetectTriangle edge-detects a triangle in the middle of the image (pointing up), leaving the rest alone...
+//
+func EdgeDetectTriangle(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	width := matrix.GetWidth()
+	height := matrix.GetHeight()
+
+	mask := monkey.PolygonMask(width, height, []image.Point{
+		{width / 2, height / 8},
+		{width * 7 / 8, height * 7 / 8},
+		{width / 8, height * 7 / 8},
+	})
+
+	newMatrix := matrix.ApplyConvolutionThroughMask(EdgeDetectConvolution, mask)
+	return newMatrix
+}
+
+//
+// SharpenHighlights sharpens the image, more so the brighter each part of it is (leaving the shadows, where
+// sharpening mostly brings out noise, nearly alone)...
+//
+func SharpenHighlights(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	mask := matrix.LuminanceMask()
+
+	newMatrix := matrix.ApplyThroughMask(mask, Sharpen)
+	return newMatrix
+}
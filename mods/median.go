@@ -0,0 +1,12 @@
+package mods
+
+import "../monkey"
+
+//
+// Median replaces each pixel with the median of the pixels within radius of it... it's great for getting rid of
+// "salt and pepper" noise without blurring the edges.
+//
+func Median(matrix monkey.ImageMatrix, radius int) monkey.ImageMatrix {
+	newMatrix := matrix.MedianFilter(radius)
+	return newMatrix
+}
@@ -0,0 +1,127 @@
+package mods
+
+import (
+	"image/color"
+	"testing"
+
+	"../monkey"
+)
+
+//
+// The mods are run one after another on the same ImageMatrix (see runMod in main.go), so none of them can change
+// the one they are given...
+//
+func TestModsLeaveTheImageMatrixAlone(t *testing.T) {
+	corners := [4]monkey.FloatPoint{{X: 1, Y: 2}, {X: 20, Y: 1}, {X: 22, Y: 15}, {X: 3, Y: 13}}
+	red := color.RGBA{255, 0, 0, 255}
+
+	allMods := map[string]func(matrix monkey.ImageMatrix){
+		"AdaptiveThresholdGaussian":          func(matrix monkey.ImageMatrix) { AdaptiveThresholdGaussian(matrix, 2, 3) },
+		"AdaptiveThresholdMean":              func(matrix monkey.ImageMatrix) { AdaptiveThresholdMean(matrix, 2, 3) },
+		"AffineTransform":                    func(matrix monkey.ImageMatrix) { AffineTransform(matrix) },
+		"ApplyConvolutionWithSampleFunction": func(matrix monkey.ImageMatrix) { ApplyConvolutionWithSampleFunction(matrix) },
+		"ApplyFunctionToEveryPixelExample":   func(matrix monkey.ImageMatrix) { ApplyFunctionToEveryPixelExample(matrix) },
+		"AverageBlur":                        func(matrix monkey.ImageMatrix) { AverageBlur(matrix) },
+		"Bilateral":                          func(matrix monkey.ImageMatrix) { Bilateral(matrix, 2, 30) },
+		"BlackHat":                           func(matrix monkey.ImageMatrix) { BlackHat(matrix, 1) },
+		"BlendWithMirror":                    func(matrix monkey.ImageMatrix) { BlendWithMirror(matrix, monkey.BlendMultiply) },
+		"BlendWithOriginal":                  func(matrix monkey.ImageMatrix) { BlendWithOriginal(matrix, matrix.Rotate180(), 0.5) },
+		"BlendWithOriginal (effect)":         func(matrix monkey.ImageMatrix) { BlendWithOriginal(matrix.Rotate180(), matrix, 0.5) },
+		"Blur":                               func(matrix monkey.ImageMatrix) { Blur(matrix, 2) },
+		"BlurWithKernelMethod":               func(matrix monkey.ImageMatrix) { BlurWithKernelMethod(matrix, 2) },
+		"Canny":                              func(matrix monkey.ImageMatrix) { Canny(matrix, 1, 10, 30) },
+		"CanvasResize":                       func(matrix monkey.ImageMatrix) { CanvasResize(matrix, 30, 10, monkey.AnchorTopLeft) },
+		"ColourSplash":                       func(matrix monkey.ImageMatrix) { ColourSplash(matrix, red, 100) },
+		"CropCentre":                         func(matrix monkey.ImageMatrix) { CropCentre(matrix, 50) },
+		"Deskew":                             func(matrix monkey.ImageMatrix) { Deskew(matrix, corners) },
+		"Dilate":                             func(matrix monkey.ImageMatrix) { Dilate(matrix, 1) },
+		"DitherOneBit":                       func(matrix monkey.ImageMatrix) { DitherOneBit(matrix, monkey.Atkinson, true) },
+		"DitherOneBitOrdered":                func(matrix monkey.ImageMatrix) { DitherOneBitOrdered(matrix, 4) },
+		"DitherToPalette":                    func(matrix monkey.ImageMatrix) { DitherToPalette(matrix, 8) },
+		"EdgeDetect":                         func(matrix monkey.ImageMatrix) { EdgeDetect(matrix) },
+		"EdgeDetectTriangle":                 func(matrix monkey.ImageMatrix) { EdgeDetectTriangle(matrix) },
+		"Emboss":                             func(matrix monkey.ImageMatrix) { Emboss(matrix) },
+		"Erode":                              func(matrix monkey.ImageMatrix) { Erode(matrix, 1) },
+		"FlipHorizontal":                     func(matrix monkey.ImageMatrix) { FlipHorizontal(matrix) },
+		"FlipVertical":                       func(matrix monkey.ImageMatrix) { FlipVertical(matrix) },
+		"FocusBlur":                          func(matrix monkey.ImageMatrix) { FocusBlur(matrix, 2) },
+		"GaussianBlur":                       func(matrix monkey.ImageMatrix) { GaussianBlur(matrix) },
+		"GreyscaleAverageWithTranslusence":   func(matrix monkey.ImageMatrix) { GreyscaleAverageWithTranslusence(matrix) },
+		"GuidedFilter":                       func(matrix monkey.ImageMatrix) { GuidedFilter(matrix, 2, 0.01) },
+		"HighPass":                           func(matrix monkey.ImageMatrix) { HighPass(matrix, 2) },
+		"HighPassSharpen":                    func(matrix monkey.ImageMatrix) { HighPassSharpen(matrix, 2, 0.5, false) },
+		"Identity":                           func(matrix monkey.ImageMatrix) { Identity(matrix) },
+		"Kuwahara":                           func(matrix monkey.ImageMatrix) { Kuwahara(matrix, 2) },
+		"MapExample":                         func(matrix monkey.ImageMatrix) { MapExample(matrix) },
+		"MapExampleInCentre":                 func(matrix monkey.ImageMatrix) { MapExampleInCentre(matrix) },
+		"MapExampleWithMask":                 func(matrix monkey.ImageMatrix) { MapExampleWithMask(matrix) },
+		"Median":                             func(matrix monkey.ImageMatrix) { Median(matrix, 1) },
+		"MorphologicalClose":                 func(matrix monkey.ImageMatrix) { MorphologicalClose(matrix, 1) },
+		"MorphologicalGradient":              func(matrix monkey.ImageMatrix) { MorphologicalGradient(matrix, 1) },
+		"MorphologicalOpen":                  func(matrix monkey.ImageMatrix) { MorphologicalOpen(matrix, 1) },
+		"NonLocalMeans":                      func(matrix monkey.ImageMatrix) { NonLocalMeans(matrix, 1, 3, 10) },
+		"PadWithColour":                      func(matrix monkey.ImageMatrix) { PadWithColour(matrix, 3, red) },
+		"PadWithEdge":                        func(matrix monkey.ImageMatrix) { PadWithEdge(matrix, 3) },
+		"PadWithMirror":                      func(matrix monkey.ImageMatrix) { PadWithMirror(matrix, 3) },
+		"PerspectiveTilt":                    func(matrix monkey.ImageMatrix) { PerspectiveTilt(matrix) },
+		"PlayOne":                            func(matrix monkey.ImageMatrix) { PlayOne(matrix) },
+		"PlayTwo":                            func(matrix monkey.ImageMatrix) { PlayTwo(matrix) },
+		"PrewittGradient":                    func(matrix monkey.ImageMatrix) { PrewittGradient(matrix) },
+		"Quantise":                           func(matrix monkey.ImageMatrix) { Quantise(matrix, monkey.Octree, 8) },
+		"Resize":                             func(matrix monkey.ImageMatrix) { Resize(matrix, 50, monkey.Lanczos3) },
+		"ResizeToFill":                       func(matrix monkey.ImageMatrix) { ResizeToFill(matrix, 10, 10) },
+		"ResizeToFit":                        func(matrix monkey.ImageMatrix) { ResizeToFit(matrix, 10, 10) },
+		"Rotate":                             func(matrix monkey.ImageMatrix) { Rotate(matrix, 30, true) },
+		"Rotate180":                          func(matrix monkey.ImageMatrix) { Rotate180(matrix) },
+		"Rotate270":                          func(matrix monkey.ImageMatrix) { Rotate270(matrix) },
+		"Rotate90":                           func(matrix monkey.ImageMatrix) { Rotate90(matrix) },
+		"ScharrGradient":                     func(matrix monkey.ImageMatrix) { ScharrGradient(matrix) },
+		"SeamCarveHorizontal":                func(matrix monkey.ImageMatrix) { SeamCarveHorizontal(matrix) },
+		"SeamCarveHorizontalAnimation":       func(matrix monkey.ImageMatrix) { SeamCarveHorizontalAnimation(matrix, 3) },
+		"SeamCarveVersusResize":              func(matrix monkey.ImageMatrix) { SeamCarveVersusResize(matrix, 80) },
+		"SeamCarveVertical":                  func(matrix monkey.ImageMatrix) { SeamCarveVertical(matrix) },
+		"SeamCarveVerticalAnimation":         func(matrix monkey.ImageMatrix) { SeamCarveVerticalAnimation(matrix, 3) },
+		"Sharpen":                            func(matrix monkey.ImageMatrix) { Sharpen(matrix) },
+		"SharpenHighlights":                  func(matrix monkey.ImageMatrix) { SharpenHighlights(matrix) },
+		"SobelGradient":                      func(matrix monkey.ImageMatrix) { SobelGradient(matrix) },
+		"SwapRGBtoGBR":                       func(matrix monkey.ImageMatrix) { SwapRGBtoGBR(matrix) },
+		"Threshold":                          func(matrix monkey.ImageMatrix) { Threshold(matrix, 100) },
+		"ThresholdOtsu":                      func(matrix monkey.ImageMatrix) { ThresholdOtsu(matrix) },
+		"ThresholdOtsuCleaned":               func(matrix monkey.ImageMatrix) { ThresholdOtsuCleaned(matrix) },
+		"ThresholdTriangle":                  func(matrix monkey.ImageMatrix) { ThresholdTriangle(matrix) },
+		"TopHat":                             func(matrix monkey.ImageMatrix) { TopHat(matrix, 1) },
+		"Transpose":                          func(matrix monkey.ImageMatrix) { Transpose(matrix) },
+		"UnsharpMask":                        func(matrix monkey.ImageMatrix) { UnsharpMask(matrix, 1.5, 1, 2, true) },
+		"Watermark":                          func(matrix monkey.ImageMatrix) { Watermark(matrix, matrix.Rotate180(), 0.5) },
+		"Watermark (mark)":                   func(matrix monkey.ImageMatrix) { Watermark(matrix.Rotate180(), matrix, 0.5) },
+	}
+
+	for name, mod := range allMods {
+		original := testMatrix()
+		matrix := original.Clone()
+		mod(matrix)
+
+		if !matrix.Equal(original) {
+			t.Errorf("%v changed the ImageMatrix it was given", name)
+		}
+	}
+}
+
+//
+// testMatrix returns a small ImageMatrix with a different (opaque) colour in every pixel, and a bright red patch
+// for the mods that pick out a colour
+//
+func testMatrix() monkey.ImageMatrix {
+	matrix := monkey.NewImageMatrix(24, 16)
+
+	for x := range matrix {
+		for y := range matrix[x] {
+			matrix[x][y] = color.RGBA{uint8(x * 10), uint8(y * 15), uint8((x + y) * 5), 255}
+			if x > 5 && x < 12 && y > 4 && y < 10 {
+				matrix[x][y] = color.RGBA{250, 10, 10, 255}
+			}
+		}
+	}
+
+	return matrix
+}
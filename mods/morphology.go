@@ -0,0 +1,59 @@
+package mods
+
+import "../monkey"
+
+//
+// Erode shrinks the bright areas of the image (each pixel takes the darkest value within the radius)...
+//
+func Erode(matrix monkey.ImageMatrix, radius int) monkey.ImageMatrix {
+	newMatrix := matrix.Erode(monkey.DiskElement(radius))
+	return newMatrix
+}
+
+//
+// Dilate grows the bright areas of the image (each pixel takes the brightest value within the radius)...
+//
+func Dilate(matrix monkey.ImageMatrix, radius int) monkey.ImageMatrix {
+	newMatrix := matrix.Dilate(monkey.DiskElement(radius))
+	return newMatrix
+}
+
+//
+// MorphologicalOpen removes bright details smaller than the radius...
+//
+func MorphologicalOpen(matrix monkey.ImageMatrix, radius int) monkey.ImageMatrix {
+	newMatrix := matrix.Open(monkey.DiskElement(radius))
+	return newMatrix
+}
+
+//
+// MorphologicalClose removes dark details smaller than the radius...
+//
+func MorphologicalClose(matrix monkey.ImageMatrix, radius int) monkey.ImageMatrix {
+	newMatrix := matrix.Close(monkey.DiskElement(radius))
+	return newMatrix
+}
+
+//
+// MorphologicalGradient outlines the edges in the image...
+//
+func MorphologicalGradient(matrix monkey.ImageMatrix, radius int) monkey.ImageMatrix {
+	newMatrix := matrix.MorphologicalGradient(monkey.SquareElement(radius))
+	return newMatrix
+}
+
+//
+// TopHat keeps just the bright details smaller than the radius...
+//
+func TopHat(matrix monkey.ImageMatrix, radius int) monkey.ImageMatrix {
+	newMatrix := matrix.TopHat(monkey.DiskElement(radius))
+	return newMatrix
+}
+
+//
+// BlackHat keeps just the dark details smaller than the radius...
+//
+func BlackHat(matrix monkey.ImageMatrix, radius int) monkey.ImageMatrix {
+	newMatrix := matrix.BlackHat(monkey.DiskElement(radius))
+	return newMatrix
+}
@@ -0,0 +1,13 @@
+package mods
+
+import "../monkey"
+
+//
+// NonLocalMeans is the heavyweight denoiser... it averages each pixel with all the pixels nearby that have a
+// similar looking patch around them. h is the strength (roughly how noisy the image is, in 0-255 colour units).
+// It's much slower than the other smoothing mods, but copes with the noise in low light photos far better.
+//
+func NonLocalMeans(matrix monkey.ImageMatrix, patchRadius, searchRadius int, h float64) monkey.ImageMatrix {
+	newMatrix := matrix.NonLocalMeans(patchRadius, searchRadius, h)
+	return newMatrix
+}
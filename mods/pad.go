@@ -0,0 +1,28 @@
+package mods
+
+import "../monkey"
+import "image/color"
+
+//
+// PadWithColour adds a border of the given size and colour around the image...
+//
+func PadWithColour(matrix monkey.ImageMatrix, size int, colour color.RGBA) monkey.ImageMatrix {
+	newMatrix := matrix.Pad(size, size, size, size, monkey.Fill{Mode: monkey.FillColour, Colour: colour})
+	return newMatrix
+}
+
+//
+// PadWithEdge adds a border of the given size around the image by repeating the edge pixels outwards...
+//
+func PadWithEdge(matrix monkey.ImageMatrix, size int) monkey.ImageMatrix {
+	newMatrix := matrix.Pad(size, size, size, size, monkey.Fill{Mode: monkey.FillEdge})
+	return newMatrix
+}
+
+//
+// PadWithMirror adds a border of the given size around the image by reflecting the image at it's edges...
+//
+func PadWithMirror(matrix monkey.ImageMatrix, size int) monkey.ImageMatrix {
+	newMatrix := matrix.Pad(size, size, size, size, monkey.Fill{Mode: monkey.FillMirror})
+	return newMatrix
+}
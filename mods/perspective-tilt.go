@@ -0,0 +1,20 @@
+package mods
+
+import "../monkey"
+import "image/color"
+
+//
+// PerspectiveTilt makes the image look like it's been photographed at an angle, by mapping it's corners onto
+// a trapezoid (the top edge is squashed in to 70% of the width)...
+//
+func PerspectiveTilt(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	width := float64(matrix.GetWidth())
+	height := float64(matrix.GetHeight())
+
+	src := [4]monkey.FloatPoint{{X: 0, Y: 0}, {X: width, Y: 0}, {X: width, Y: height}, {X: 0, Y: height}}
+	dst := [4]monkey.FloatPoint{{X: width * 0.15, Y: 0}, {X: width * 0.85, Y: 0}, {X: width, Y: height}, {X: 0, Y: height}}
+
+	h := monkey.HomographyFromQuad(src, dst)
+	newMatrix := matrix.PerspectiveTransform(h, matrix.GetWidth(), matrix.GetHeight(), monkey.Bilinear, color.RGBA{})
+	return newMatrix
+}
@@ -0,0 +1,12 @@
+package mods
+
+import "../monkey"
+
+//
+// Quantise reduces the image to (at most) numColours colours, picked with the given palette method, by mapping
+// every pixel to the nearest colour in the palette (no dithering)
+//
+func Quantise(matrix monkey.ImageMatrix, method monkey.PaletteMethod, numColours int) monkey.ImageMatrix {
+	newMatrix := matrix.MapToPalette(matrix.Palette(method, numColours))
+	return newMatrix
+}
@@ -0,0 +1,34 @@
+package mods
+
+import "../monkey"
+
+//
+// Resize scales the image by the given percentage (eg. 50 halves the width and height) with the given
+// resample filter...
+//
+func Resize(matrix monkey.ImageMatrix, percentage int, filter monkey.ResampleFilter) monkey.ImageMatrix {
+	width := matrix.GetWidth() * percentage / 100
+	if width < 1 {
+		width = 1
+	}
+
+	newMatrix := matrix.Resize(width, 0, filter)
+	return newMatrix
+}
+
+//
+// ResizeToFit scales the image (keeping the aspect ratio) so that it fits inside width x height...
+//
+func ResizeToFit(matrix monkey.ImageMatrix, width, height int) monkey.ImageMatrix {
+	newMatrix := matrix.ResizeToFit(width, height, monkey.Lanczos3)
+	return newMatrix
+}
+
+//
+// ResizeToFill scales the image (keeping the aspect ratio) so that it covers width x height, cropping off
+// whatever doesn't fit...
+//
+func ResizeToFill(matrix monkey.ImageMatrix, width, height int) monkey.ImageMatrix {
+	newMatrix := matrix.ResizeToFill(width, height, monkey.Lanczos3)
+	return newMatrix
+}
@@ -0,0 +1,43 @@
+package mods
+
+import "../monkey"
+import "image/color"
+
+//
+// Rotate90 rotates the image 90 degrees clockwise...
+//
+func Rotate90(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	newMatrix := matrix.Rotate90()
+	return newMatrix
+}
+
+//
+// Rotate180 turns the image upside down...
+//
+func Rotate180(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	newMatrix := matrix.Rotate180()
+	return newMatrix
+}
+
+//
+// Rotate270 rotates the image 90 degrees anti-clockwise...
+//
+func Rotate270(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	newMatrix := matrix.Rotate270()
+	return newMatrix
+}
+
+//
+// Rotate rotates the image clockwise by any number of degrees (with bicubic interpolation), growing the
+// canvas to fit the rotated image if expand is true, or cropping the corners off if it is false. The new
+// areas of the canvas are left transparent.
+//
+func Rotate(matrix monkey.ImageMatrix, degrees float64, expand bool) monkey.ImageMatrix {
+	mode := monkey.RotateCrop
+	if expand {
+		mode = monkey.RotateExpand
+	}
+
+	newMatrix := matrix.Rotate(degrees, monkey.CatmullRom, color.RGBA{}, mode)
+	return newMatrix
+}
@@ -0,0 +1,22 @@
+package mods
+
+import "../monkey"
+
+//
+// SeamCarveHorizontalAnimation carves the given number of horizontal seams out of the image, and returns the
+// frames showing each seam (painted in monkey.SeamColour) before it's removed, followed by the result
+//
+func SeamCarveHorizontalAnimation(matrix monkey.ImageMatrix, seams int) monkey.Animation {
+	animation := monkey.Animation{}
+	matrix.SeamCarveHorizontalBy(seams, &animation)
+	return animation
+}
+
+//
+// SeamCarveVerticalAnimation is SeamCarveHorizontalAnimation for vertical seams (so the image gets narrower)
+//
+func SeamCarveVerticalAnimation(matrix monkey.ImageMatrix, seams int) monkey.Animation {
+	animation := monkey.Animation{}
+	matrix.SeamCarveVerticalBy(seams, &animation)
+	return animation
+}
@@ -0,0 +1,27 @@
+package mods
+
+import "../monkey"
+import "image/color"
+
+//
+// SeamCarveVersusResize carves percentage% of the height out of the image, and puts the result (on the left)
+// next to the original image resized (with Lanczos3) to the same dimensions (on the right), so that we can
+// compare the two ways of retargeting...
+//
+func SeamCarveVersusResize(matrix monkey.ImageMatrix, percentage int) monkey.ImageMatrix {
+	seams := matrix.GetHeight() * percentage / 100
+	carved := matrix.SeamCarveHorizontalBy(seams, nil)
+	resized := matrix.Resize(carved.GetWidth(), carved.GetHeight(), monkey.Lanczos3)
+
+	// leave a (transparent) gap between the two images so it's clear where one ends and the other starts
+	gap := 4
+	newMatrix := monkey.ImageMatrix{}
+	newMatrix = append(newMatrix, carved...)
+
+	for i := 0; i < gap; i++ {
+		newMatrix = append(newMatrix, make([]color.RGBA, carved.GetHeight()))
+	}
+
+	newMatrix = append(newMatrix, resized...)
+	return newMatrix
+}
@@ -0,0 +1,11 @@
+package mods
+
+import "../monkey"
+
+//
+// SeamCarveVertical ...
+//
+func SeamCarveVertical(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	newMatrix := matrix.SeamCarveVertical()
+	return newMatrix
+}
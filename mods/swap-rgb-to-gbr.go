@@ -20,13 +20,16 @@ func SwapRGBtoGBR(matrix monkey.ImageMatrix) monkey.ImageMatrix {
 	width := matrix.GetWidth()
 	height := matrix.GetHeight()
 
+	// (work on a copy, mods never change the ImageMatrix they are given)
+	newMatrix := matrix.Clone()
+
 	for x := 0; x < width; x++ {
 		for y := 0; y < height; y++ {
-			colour := matrix[x][y]
+			colour := newMatrix[x][y]
 			colour.R, colour.G, colour.B = colour.G, colour.B, colour.R
-			matrix[x][y] = colour
+			newMatrix[x][y] = colour
 		}
 	}
 
-	return matrix
+	return newMatrix
 }
@@ -0,0 +1,56 @@
+package mods
+
+import "../monkey"
+
+//
+// Threshold turns the image into black and white; pixels brighter than level become white...
+//
+func Threshold(matrix monkey.ImageMatrix, level uint8) monkey.ImageMatrix {
+	newMatrix := matrix.Threshold(level).ImageMatrix()
+	return newMatrix
+}
+
+//
+// ThresholdOtsu turns the image into black and white, with the level picked automatically by Otsu's method...
+//
+func ThresholdOtsu(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	newMatrix := matrix.Threshold(matrix.OtsuThreshold()).ImageMatrix()
+	return newMatrix
+}
+
+//
+// ThresholdTriangle turns the image into black and white, with the level picked automatically by the triangle
+// method...
+//
+func ThresholdTriangle(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	newMatrix := matrix.Threshold(matrix.TriangleThreshold()).ImageMatrix()
+	return newMatrix
+}
+
+//
+// AdaptiveThresholdMean turns the image into black and white, comparing each pixel to the average of the
+// pixels within radius of it (minus c)...
+//
+func AdaptiveThresholdMean(matrix monkey.ImageMatrix, radius int, c float64) monkey.ImageMatrix {
+	newMatrix := matrix.AdaptiveThreshold(monkey.AdaptiveMean, radius, c).ImageMatrix()
+	return newMatrix
+}
+
+//
+// AdaptiveThresholdGaussian turns the image into black and white, comparing each pixel to the gaussian
+// weighted average of the pixels within radius of it (minus c)...
+//
+func AdaptiveThresholdGaussian(matrix monkey.ImageMatrix, radius int, c float64) monkey.ImageMatrix {
+	newMatrix := matrix.AdaptiveThreshold(monkey.AdaptiveGaussian, radius, c).ImageMatrix()
+	return newMatrix
+}
+
+//
+// ThresholdOtsuCleaned is ThresholdOtsu followed by a morphological open and close of the mask, which gets rid
+// of the isolated specks and pin holes that thresholding a noisy scan leaves behind...
+//
+func ThresholdOtsuCleaned(matrix monkey.ImageMatrix) monkey.ImageMatrix {
+	se := monkey.SquareElement(1)
+	newMatrix := matrix.Threshold(matrix.OtsuThreshold()).Open(se).Close(se).ImageMatrix()
+	return newMatrix
+}
@@ -0,0 +1,14 @@
+package mods
+
+import "../monkey"
+
+//
+// UnsharpMask sharpens the image with an unsharp mask... radius is the size (gaussian sigma) of the detail that
+// is sharpened, amount is how much it's boosted by (eg. 0.8 = 80%), and differences smaller than threshold
+// (0-255) are left alone so noise isn't sharpened. If luminanceOnly is true, the colours aren't sharpened
+// separately so no coloured fringes appear along the edges.
+//
+func UnsharpMask(matrix monkey.ImageMatrix, radius, amount float64, threshold uint8, luminanceOnly bool) monkey.ImageMatrix {
+	newMatrix := matrix.UnsharpMask(radius, amount, threshold, luminanceOnly)
+	return newMatrix
+}
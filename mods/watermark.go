@@ -0,0 +1,19 @@
+package mods
+
+import "../monkey"
+import "image"
+
+//
+// Watermark places the mark in the bottom right corner of the image (with a small margin) at the given
+// opacity...
+//
+func Watermark(matrix, mark monkey.ImageMatrix, opacity float64) monkey.ImageMatrix {
+	margin := 10
+	offset := image.Point{
+		matrix.GetWidth() - mark.GetWidth() - margin,
+		matrix.GetHeight() - mark.GetHeight() - margin,
+	}
+
+	newMatrix := monkey.Composite(matrix, mark, monkey.CompositeOver, offset, opacity)
+	return newMatrix
+}
@@ -0,0 +1,113 @@
+package monkey
+
+import "image"
+import "image/draw"
+import "image/gif"
+import "../util"
+
+//
+// Frame is a single frame of an animation. Matrix is the whole picture as it's shown at that point (not just the
+// part of it that changed, which is all a gif stores for most frames), Delay is how long it's shown for (in
+// 100ths of a second), and Disposal is what the gif said to do with the frame once it's time was up (one of
+// gif.DisposalNone, gif.DisposalBackground or gif.DisposalPrevious; 0 if it wasn't given).
+//
+type Frame struct {
+	Matrix   ImageMatrix
+	Delay    int
+	Disposal byte
+}
+
+//
+// Animation is a sequence of frames, and how many times to go round them (0 to loop forever, -1 to play them
+// once, and n to play them n+1 times; the same as gif.GIF's LoopCount)
+//
+type Animation struct {
+	Frames    []Frame
+	LoopCount int
+}
+
+//
+// AnimationFromGIF builds an Animation from a decoded gif (eg. from gif.DecodeAll). Each frame of a gif only holds
+// the part of the picture that changes (and may be transparent where the previous frame should show through),
+// so we draw each frame onto a canvas, clearing or restoring it between frames as their disposal method says,
+// and keep a copy of the canvas for every frame.
+//
+func AnimationFromGIF(g *gif.GIF) Animation {
+	width, height := g.Config.Width, g.Config.Height
+	if width == 0 || height == 0 {
+		for _, frame := range g.Image {
+			width = maxInt(width, frame.Bounds().Max.X)
+			height = maxInt(height, frame.Bounds().Max.Y)
+		}
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	animation := Animation{LoopCount: g.LoopCount}
+
+	for i, frame := range g.Image {
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+
+		delay := 0
+		if i < len(g.Delay) {
+			delay = g.Delay[i]
+		}
+
+		// keep what was there before, in case this frame has to be undone once it's been shown...
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(canvas.Bounds())
+			draw.Draw(previous, previous.Bounds(), canvas, image.Point{}, draw.Src)
+		}
+
+		// the transparent pixels of the frame let the canvas show through
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		animation.Frames = append(animation.Frames, Frame{ImageToImageMatrix(canvas), delay, disposal})
+
+		switch disposal {
+		case gif.DisposalBackground:
+			// (browsers all clear to transparent rather than the background colour, so we do too)
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	return animation
+}
+
+//
+// ApplyToEveryFrame returns a new Animation with the function (eg. a mod) applied to the matrix of every frame;
+// the timing of the frames is kept the same
+//
+func (a Animation) ApplyToEveryFrame(modFunc func(ImageMatrix) ImageMatrix) Animation {
+	newAnimation := Animation{LoopCount: a.LoopCount}
+	for _, frame := range a.Frames {
+		newAnimation.Frames = append(newAnimation.Frames, Frame{modFunc(frame.Matrix), frame.Delay, frame.Disposal})
+	}
+
+	return newAnimation
+}
+
+//
+// GIF returns the animation as a gif.GIF, ready to be saved (eg. with util.SaveGIFToFile). Every frame gets it's
+// own palette of (at most) numColours colours, picked by quantizer and drawn with drawer (see
+// util.PalettedImage). As our frames are whole pictures rather than just the parts that changed, they are all
+// written with gif.DisposalBackground, so each one replaces the last (even if it has transparent areas).
+//
+func (a Animation) GIF(numColours int, quantizer draw.Quantizer, drawer draw.Drawer) *gif.GIF {
+	g := &gif.GIF{LoopCount: a.LoopCount}
+
+	for _, frame := range a.Frames {
+		g.Image = append(g.Image, util.PalettedImage(ImageMatrixToImage(frame.Matrix), numColours, quantizer, drawer))
+		g.Delay = append(g.Delay, frame.Delay)
+		g.Disposal = append(g.Disposal, gif.DisposalBackground)
+
+		g.Config.Width = maxInt(g.Config.Width, frame.Matrix.GetWidth())
+		g.Config.Height = maxInt(g.Config.Height, frame.Matrix.GetHeight())
+	}
+
+	return g
+}
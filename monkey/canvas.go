@@ -0,0 +1,207 @@
+package monkey
+
+import "image"
+import "image/color"
+import "log"
+
+//
+// FillMode decides how we fill in the new pixels when we pad an image...
+//
+type FillMode int
+
+const (
+	// FillColour sets the new pixels to a single colour
+	FillColour FillMode = iota
+
+	// FillEdge repeats the nearest edge pixel of the image outwards
+	FillEdge
+
+	// FillMirror reflects the image back on itself at the edges (the edge pixel is repeated, eg. cba|abc)
+	FillMirror
+)
+
+//
+// Fill is how the new pixels should be filled in when padding an image (Colour is only used with FillColour)
+//
+type Fill struct {
+	Mode   FillMode
+	Colour color.RGBA
+}
+
+//
+// Anchor is the point of the image that stays put when we resize the canvas around it...
+//
+type Anchor int
+
+//
+// The anchors we support (the 9 points you'd find on a GIMP / Photoshop canvas size dialog)
+//
+const (
+	AnchorTopLeft Anchor = iota
+	AnchorTop
+	AnchorTopRight
+	AnchorLeft
+	AnchorCentre
+	AnchorRight
+	AnchorBottomLeft
+	AnchorBottom
+	AnchorBottomRight
+)
+
+//
+// Bounds returns the rectangle covered by the image (which always starts at 0,0)
+//
+func (im ImageMatrix) Bounds() image.Rectangle {
+	return image.Rect(0, 0, im.GetWidth(), im.GetHeight())
+}
+
+//
+// Crop returns the part of the image inside rect (clipped to the image bounds), with the top left corner of
+// rect becoming (0, 0) in the new ImageMatrix.
+//
+func (im ImageMatrix) Crop(rect image.Rectangle) ImageMatrix {
+	return im.CropInPlace(rect).Clone()
+}
+
+//
+// CropInPlace is Crop without copying any pixels; as the ImageMatrix is stored as columns, the new ImageMatrix's
+// columns can just be slices of the original columns. This makes it very cheap, but it also means that
+// changing a pixel in one of them changes it in the other!
+//
+func (im ImageMatrix) CropInPlace(rect image.Rectangle) ImageMatrix {
+	rect = rect.Intersect(im.Bounds())
+	if rect.Empty() {
+		log.Fatalln("The crop rectangle doesn't overlap the image:", rect)
+	}
+
+	newMatrix := ImageMatrix{}
+
+	for x := rect.Min.X; x < rect.Max.X; x++ {
+		// (the full slice expression stops an append to the new column from writing over the original one)
+		newMatrix = append(newMatrix, im[x][rect.Min.Y:rect.Max.Y:rect.Max.Y])
+	}
+
+	return newMatrix
+}
+
+//
+// Pad returns a new ImageMatrix with the given number of pixels added to each side of the image, filled in
+// according to fill.
+//
+func (im ImageMatrix) Pad(top, right, bottom, left int, fill Fill) ImageMatrix {
+	if top < 0 || right < 0 || bottom < 0 || left < 0 {
+		log.Fatalln("Can not pad an image by a negative amount:", top, right, bottom, left)
+	}
+
+	width := im.GetWidth()
+	height := im.GetHeight()
+	newWidth := width + left + right
+	newHeight := height + top + bottom
+	newMatrix := ImageMatrix{}
+
+	for x := 0; x < newWidth; x++ {
+		column := make(ImageRow, newHeight)
+		srcX, insideX := padSourceIndex(x-left, width, fill.Mode)
+
+		for y := 0; y < newHeight; y++ {
+			srcY, insideY := padSourceIndex(y-top, height, fill.Mode)
+
+			if insideX && insideY {
+				column[y] = im[srcX][srcY]
+			} else {
+				column[y] = fill.Colour
+			}
+		}
+
+		newMatrix = append(newMatrix, column)
+	}
+
+	return newMatrix
+}
+
+//
+// padSourceIndex works out which pixel (along one axis) of the source image a padded pixel should be copied
+// from. It returns false if the pixel should be set to the fill colour instead.
+//
+func padSourceIndex(i, size int, mode FillMode) (int, bool) {
+	if i >= 0 && i < size {
+		return i, true
+	}
+
+	switch mode {
+	case FillEdge:
+		return clampInt(i, 0, size-1), true
+
+	case FillMirror:
+		// reflecting repeats every 2*size pixels (forwards and then backwards)...
+		period := 2 * size
+		i = ((i % period) + period) % period
+		if i >= size {
+			i = period - 1 - i
+		}
+		return i, true
+	}
+
+	return 0, false
+}
+
+//
+// CanvasResize returns a new width x height ImageMatrix with the image placed on it according to the anchor
+// (eg. AnchorCentre keeps the image in the middle of the new canvas). If the canvas is bigger than the image,
+// the new areas are set to the background colour; if it is smaller, the image is cropped.
+//
+func (im ImageMatrix) CanvasResize(width, height int, anchor Anchor, background color.RGBA) ImageMatrix {
+	if width <= 0 || height <= 0 {
+		log.Fatalln("Invalid canvas size:", width, height)
+	}
+
+	// how far across the spare (or missing) space the anchor puts the image, in halves (0, 1/2 or 2/2)
+	var fx, fy int
+	switch anchor {
+	case AnchorTopLeft:
+		fx, fy = 0, 0
+	case AnchorTop:
+		fx, fy = 1, 0
+	case AnchorTopRight:
+		fx, fy = 2, 0
+	case AnchorLeft:
+		fx, fy = 0, 1
+	case AnchorCentre:
+		fx, fy = 1, 1
+	case AnchorRight:
+		fx, fy = 2, 1
+	case AnchorBottomLeft:
+		fx, fy = 0, 2
+	case AnchorBottom:
+		fx, fy = 1, 2
+	case AnchorBottomRight:
+		fx, fy = 2, 2
+	default:
+		log.Fatalln("Unknown anchor:", int(anchor))
+	}
+
+	// offset of the image on the new canvas (negative if the image overhangs the canvas)
+	offsetX := (width - im.GetWidth()) * fx / 2
+	offsetY := (height - im.GetHeight()) * fy / 2
+
+	newMatrix := ImageMatrix{}
+
+	for x := 0; x < width; x++ {
+		column := make(ImageRow, height)
+		srcX := x - offsetX
+
+		for y := 0; y < height; y++ {
+			srcY := y - offsetY
+
+			if srcX >= 0 && srcY >= 0 && srcX < im.GetWidth() && srcY < im.GetHeight() {
+				column[y] = im[srcX][srcY]
+			} else {
+				column[y] = background
+			}
+		}
+
+		newMatrix = append(newMatrix, column)
+	}
+
+	return newMatrix
+}
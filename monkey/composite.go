@@ -0,0 +1,305 @@
+package monkey
+
+import "image"
+import "log"
+import "math"
+
+//
+// CompositeOp is how a source image is combined with the destination image it's being composited onto...
+//
+// The Porter-Duff operators decide which parts of each image are kept based on their alpha (coverage), and the
+// blend modes decide how the colours are mixed where the two images overlap (they are then composited "over").
+// See https://www.w3.org/TR/compositing-1/ for the maths of all of them.
+//
+type CompositeOp int
+
+//
+// The Porter-Duff operators...
+//
+const (
+	// CompositeOver puts the source on top of the destination (the normal way of layering images)
+	CompositeOver CompositeOp = iota
+
+	// CompositeIn keeps the source only where the destination is (the destination itself is dropped)
+	CompositeIn
+
+	// CompositeOut keeps the source only where the destination isn't (the destination itself is dropped)
+	CompositeOut
+
+	// CompositeAtop puts the source on top of the destination, but only where the destination is
+	CompositeAtop
+
+	// CompositeXor keeps the source where the destination isn't, and the destination where the source isn't
+	CompositeXor
+)
+
+//
+// ...and the blend modes
+//
+const (
+	BlendMultiply CompositeOp = iota + 100
+	BlendScreen
+	BlendOverlay
+	BlendSoftLight
+	BlendHardLight
+	BlendDifference
+	BlendColourDodge
+	BlendColourBurn
+	BlendHue
+	BlendSaturation
+	BlendColour
+	BlendLuminosity
+)
+
+//
+// Composite returns a new ImageMatrix with src composited onto dst (src's top left corner is placed at offset
+// in dst) using the given operator. Opacity (0 to 1) scales the alpha of src, so an opacity of 0.4 with
+// CompositeOver gives you "60% of the original with 40% of the effect".
+//
+// The new image is always the size of dst, and only the part of dst that src overlaps is changed.
+//
+// Remember the colours in an ImageMatrix have premultiplied alpha (just like color.RGBA), so we work with that
+// throughout, only un-premultiplying where the blend mode maths needs the straight colour.
+//
+func Composite(dst, src ImageMatrix, op CompositeOp, offset image.Point, opacity float64) ImageMatrix {
+	if opacity < 0 || opacity > 1 {
+		log.Fatalln("The opacity must be between 0 and 1:", opacity)
+	}
+
+	width := dst.GetWidth()
+	overlap := src.Bounds().Add(offset).Intersect(dst.Bounds())
+	newMatrix := ImageMatrix{}
+
+	for x := 0; x < width; x++ {
+		column := append(ImageRow{}, dst[x]...)
+
+		if x >= overlap.Min.X && x < overlap.Max.X {
+			for y := overlap.Min.Y; y < overlap.Max.Y; y++ {
+				d := dst[x][y]
+				s := src[x-offset.X][y-offset.Y]
+
+				// everything in the range 0-1 (premultiplied)...
+				sc := [3]float64{float64(s.R) / 255 * opacity, float64(s.G) / 255 * opacity, float64(s.B) / 255 * opacity}
+				sa := float64(s.A) / 255 * opacity
+				dc := [3]float64{float64(d.R) / 255, float64(d.G) / 255, float64(d.B) / 255}
+				da := float64(d.A) / 255
+
+				var rc [3]float64
+				var ra float64
+
+				if op < BlendMultiply {
+					rc, ra = porterDuff(op, sc, sa, dc, da)
+				} else {
+					rc, ra = blend(op, sc, sa, dc, da)
+				}
+
+				column[y] = premultipliedRGBA(rc[0]*255, rc[1]*255, rc[2]*255, ra*255)
+			}
+		}
+
+		newMatrix = append(newMatrix, column)
+	}
+
+	return newMatrix
+}
+
+//
+// porterDuff applies one of the Porter-Duff operators to a (premultiplied) source and destination colour.
+// Every operator boils down to result = Fa*source + Fb*destination; it's just the Fa and Fb that change.
+//
+func porterDuff(op CompositeOp, sc [3]float64, sa float64, dc [3]float64, da float64) ([3]float64, float64) {
+	var fa, fb float64
+
+	switch op {
+	case CompositeOver:
+		fa, fb = 1, 1-sa
+	case CompositeIn:
+		fa, fb = da, 0
+	case CompositeOut:
+		fa, fb = 1-da, 0
+	case CompositeAtop:
+		fa, fb = da, 1-sa
+	case CompositeXor:
+		fa, fb = 1-da, 1-sa
+	default:
+		log.Fatalln("Unknown composite operator:", int(op))
+	}
+
+	var rc [3]float64
+	for i := range rc {
+		rc[i] = fa*sc[i] + fb*dc[i]
+	}
+
+	return rc, fa*sa + fb*da
+}
+
+//
+// blend mixes a (premultiplied) source and destination colour with one of the blend modes, and composites the
+// result over the destination. Where the destination is transparent we just get the source back, and where the
+// source is transparent we just get the destination back.
+//
+func blend(op CompositeOp, sc [3]float64, sa float64, dc [3]float64, da float64) ([3]float64, float64) {
+	// the blend functions work on straight (not premultiplied) colours
+	cs := unpremultiply(sc, sa)
+	cb := unpremultiply(dc, da)
+
+	var mixed [3]float64
+
+	switch op {
+	case BlendHue:
+		mixed = setLum(setSat(cs, sat(cb)), lum(cb))
+	case BlendSaturation:
+		mixed = setLum(setSat(cb, sat(cs)), lum(cb))
+	case BlendColour:
+		mixed = setLum(cs, lum(cb))
+	case BlendLuminosity:
+		mixed = setLum(cb, lum(cs))
+	default:
+		for i := range mixed {
+			mixed[i] = blendChannel(op, cb[i], cs[i])
+		}
+	}
+
+	var rc [3]float64
+	for i := range rc {
+		rc[i] = sc[i]*(1-da) + sa*da*mixed[i] + dc[i]*(1-sa)
+	}
+
+	return rc, sa + da*(1-sa)
+}
+
+//
+// blendChannel is the blend function B(cb, cs) for the "separable" blend modes, ie. those where each of the
+// red, green and blue channels can be worked out on it's own
+//
+func blendChannel(op CompositeOp, cb, cs float64) float64 {
+	switch op {
+	case BlendMultiply:
+		return cb * cs
+
+	case BlendScreen:
+		return cb + cs - cb*cs
+
+	case BlendOverlay:
+		// overlay is hard light with the layers swapped
+		return blendChannel(BlendHardLight, cs, cb)
+
+	case BlendHardLight:
+		if cs <= 0.5 {
+			return cb * 2 * cs
+		}
+		return blendChannel(BlendScreen, cb, 2*cs-1)
+
+	case BlendSoftLight:
+		if cs <= 0.5 {
+			return cb - (1-2*cs)*cb*(1-cb)
+		}
+
+		d := math.Sqrt(cb)
+		if cb <= 0.25 {
+			d = ((16*cb-12)*cb + 4) * cb
+		}
+		return cb + (2*cs-1)*(d-cb)
+
+	case BlendDifference:
+		return math.Abs(cb - cs)
+
+	case BlendColourDodge:
+		if cb == 0 {
+			return 0
+		} else if cs >= 1 {
+			return 1
+		}
+		return math.Min(1, cb/(1-cs))
+
+	case BlendColourBurn:
+		if cb >= 1 {
+			return 1
+		} else if cs <= 0 {
+			return 0
+		}
+		return 1 - math.Min(1, (1-cb)/cs)
+	}
+
+	log.Fatalln("Unknown blend mode:", int(op))
+	return 0
+}
+
+//
+// unpremultiply divides the colour by it's alpha (a fully transparent colour is returned as black)
+//
+func unpremultiply(c [3]float64, a float64) [3]float64 {
+	if a == 0 {
+		return [3]float64{}
+	}
+
+	return [3]float64{c[0] / a, c[1] / a, c[2] / a}
+}
+
+//
+// lum returns the luminosity of a colour (as defined for the non-separable blend modes)
+//
+func lum(c [3]float64) float64 {
+	return 0.3*c[0] + 0.59*c[1] + 0.11*c[2]
+}
+
+//
+// clipColour brings a colour that has gone out of the 0-1 range back into it, keeping it's luminosity
+//
+func clipColour(c [3]float64) [3]float64 {
+	l := lum(c)
+	n := math.Min(c[0], math.Min(c[1], c[2]))
+	x := math.Max(c[0], math.Max(c[1], c[2]))
+
+	for i := range c {
+		if n < 0 {
+			c[i] = l + (c[i]-l)*l/(l-n)
+		}
+
+		if x > 1 {
+			c[i] = l + (c[i]-l)*(1-l)/(x-l)
+		}
+	}
+
+	return c
+}
+
+//
+// setLum returns the colour c shifted to have the luminosity l
+//
+func setLum(c [3]float64, l float64) [3]float64 {
+	d := l - lum(c)
+	return clipColour([3]float64{c[0] + d, c[1] + d, c[2] + d})
+}
+
+//
+// sat returns the saturation of a colour (the difference between it's largest and smallest channel)
+//
+func sat(c [3]float64) float64 {
+	return math.Max(c[0], math.Max(c[1], c[2])) - math.Min(c[0], math.Min(c[1], c[2]))
+}
+
+//
+// setSat returns the colour c with it's saturation changed to s (keeping the hue)
+//
+func setSat(c [3]float64, s float64) [3]float64 {
+	// find which channels hold the max, mid and min values
+	max, mid, min := 0, 1, 2
+	if c[max] < c[mid] {
+		max, mid = mid, max
+	}
+	if c[mid] < c[min] {
+		mid, min = min, mid
+	}
+	if c[max] < c[mid] {
+		max, mid = mid, max
+	}
+
+	var result [3]float64
+	if c[max] > c[min] {
+		result[mid] = (c[mid] - c[min]) * s / (c[max] - c[min])
+		result[max] = s
+	}
+
+	return result
+}
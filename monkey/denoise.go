@@ -0,0 +1,242 @@
+package monkey
+
+import "image/color"
+import "log"
+import "math"
+
+//
+// MedianFilter returns a new ImageMatrix where each of the red, green and blue channels of a pixel is set to the
+// median of that channel in the (2*radius + 1) square around it (the edge pixels are repeated outwards). It
+// removes "salt and pepper" noise while keeping edges sharp. The alpha is left alone.
+//
+// Rather than sorting the window for every pixel, we keep a histogram of the window for each channel and slide
+// it down the column, only adding the row that comes into the window and removing the one that leaves it
+// (Huang's algorithm), so large radii are still quick.
+//
+func (im ImageMatrix) MedianFilter(radius int) ImageMatrix {
+	if radius < 1 {
+		log.Fatalln("The median filter radius must be at least 1:", radius)
+	}
+
+	width := im.GetWidth()
+	height := im.GetHeight()
+	size := 2*radius + 1
+	middle := size*size/2 + 1 // the count we need to reach in the histogram to find the median
+	newMatrix := ImageMatrix{}
+
+	for x := 0; x < width; x++ {
+		column := make(ImageRow, height)
+		var red, green, blue [256]int
+
+		// add (or remove, if change is -1) a row of the window to the histograms
+		updateRow := func(y, change int) {
+			j := clampInt(y, 0, height-1)
+			for i := x - radius; i <= x+radius; i++ {
+				c := im[clampInt(i, 0, width-1)][j]
+				red[c.R] += change
+				green[c.G] += change
+				blue[c.B] += change
+			}
+		}
+
+		for y := -radius; y <= radius; y++ {
+			updateRow(y, 1)
+		}
+
+		for y := 0; y < height; y++ {
+			if y > 0 {
+				updateRow(y-radius-1, -1)
+				updateRow(y+radius, 1)
+			}
+
+			a := im[x][y].A
+			column[y] = color.RGBA{
+				minUint8(histogramMedian(&red, middle), a),
+				minUint8(histogramMedian(&green, middle), a),
+				minUint8(histogramMedian(&blue, middle), a),
+				a,
+			}
+		}
+
+		newMatrix = append(newMatrix, column)
+	}
+
+	return newMatrix
+}
+
+//
+// histogramMedian returns the value at which the running count of the histogram reaches middle
+//
+func histogramMedian(histogram *[256]int, middle int) uint8 {
+	count := 0
+	for v, n := range histogram {
+		count += n
+		if count >= middle {
+			return uint8(v)
+		}
+	}
+
+	return 255
+}
+
+//
+// BilateralFilter returns a new ImageMatrix where each pixel is a weighted average of the pixels around it, but
+// the weight depends both on how far away the other pixel is (spatialSigma, in pixels) and how different it's
+// colour is (rangeSigma, in 0-255 colour units). Pixels on the other side of an edge are very different in
+// colour, so they get almost no weight, and the edge stays sharp while flat areas are smoothed.
+// See https://en.wikipedia.org/wiki/Bilateral_filter
+//
+func (im ImageMatrix) BilateralFilter(spatialSigma, rangeSigma float64) ImageMatrix {
+	if spatialSigma <= 0 || rangeSigma <= 0 {
+		log.Fatalln("The bilateral filter sigmas must be greater than 0:", spatialSigma, rangeSigma)
+	}
+
+	width := im.GetWidth()
+	height := im.GetHeight()
+	radius := int(math.Ceil(2 * spatialSigma))
+
+	// the spatial weights are the same for every pixel, so work them out once...
+	spatial := NewFloatMatrix(2*radius+1, 2*radius+1)
+	for i := -radius; i <= radius; i++ {
+		for j := -radius; j <= radius; j++ {
+			spatial[i+radius][j+radius] = math.Exp(-float64(i*i+j*j) / (2 * spatialSigma * spatialSigma))
+		}
+	}
+
+	newMatrix := ImageMatrix{}
+
+	for x := 0; x < width; x++ {
+		column := make(ImageRow, height)
+
+		for y := 0; y < height; y++ {
+			centre := im[x][y]
+			var r, g, b, total float64
+
+			for i := maxInt(0, x-radius); i <= minInt(width-1, x+radius); i++ {
+				for j := maxInt(0, y-radius); j <= minInt(height-1, y+radius); j++ {
+					c := im[i][j]
+					dr := float64(c.R) - float64(centre.R)
+					dg := float64(c.G) - float64(centre.G)
+					db := float64(c.B) - float64(centre.B)
+
+					w := spatial[i-x+radius][j-y+radius] * math.Exp(-(dr*dr+dg*dg+db*db)/(2*rangeSigma*rangeSigma))
+					r += float64(c.R) * w
+					g += float64(c.G) * w
+					b += float64(c.B) * w
+					total += w
+				}
+			}
+
+			column[y] = premultipliedRGBA(r/total, g/total, b/total, float64(centre.A))
+		}
+
+		newMatrix = append(newMatrix, column)
+	}
+
+	return newMatrix
+}
+
+//
+// KuwaharaFilter returns a new ImageMatrix where each pixel is set to the average colour of whichever of the 4
+// (radius + 1) square regions that meet at the pixel (top left, top right, bottom left, bottom right) has the
+// least variation in brightness. It flattens areas out into a painterly look while keeping the edges sharp.
+// See https://en.wikipedia.org/wiki/Kuwahara_filter
+//
+func (im ImageMatrix) KuwaharaFilter(radius int) ImageMatrix {
+	if radius < 1 {
+		log.Fatalln("The Kuwahara filter radius must be at least 1:", radius)
+	}
+
+	width := im.GetWidth()
+	height := im.GetHeight()
+	brightness := im.Luminance()
+	newMatrix := ImageMatrix{}
+
+	for x := 0; x < width; x++ {
+		column := make(ImageRow, height)
+
+		for y := 0; y < height; y++ {
+			bestVariance := math.Inf(1)
+			var best [3]float64
+
+			for _, quadrant := range [4][2]int{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+				var sum [3]float64
+				var lumSum, lumSquares, count float64
+
+				for i := 0; i <= radius; i++ {
+					for j := 0; j <= radius; j++ {
+						qx := clampInt(x+i*quadrant[0], 0, width-1)
+						qy := clampInt(y+j*quadrant[1], 0, height-1)
+						c := im[qx][qy]
+						l := brightness[qx][qy]
+
+						sum[0] += float64(c.R)
+						sum[1] += float64(c.G)
+						sum[2] += float64(c.B)
+						lumSum += l
+						lumSquares += l * l
+						count++
+					}
+				}
+
+				mean := lumSum / count
+				variance := lumSquares/count - mean*mean
+
+				if variance < bestVariance {
+					bestVariance = variance
+					best = [3]float64{sum[0] / count, sum[1] / count, sum[2] / count}
+				}
+			}
+
+			column[y] = premultipliedRGBA(best[0], best[1], best[2], float64(im[x][y].A))
+		}
+
+		newMatrix = append(newMatrix, column)
+	}
+
+	return newMatrix
+}
+
+//
+// GuidedFilter returns a new ImageMatrix smoothed with the guided filter (He, Sun and Tang). Within every
+// (2*radius + 1) window, the output is modelled as a straight line fit of the guide image's luminance, so edges
+// in the guide are kept in the output. epsilon (in 0-1 brightness units squared, eg. 0.01) is how strong an
+// edge must be to be kept; the bigger it is, the more the image is smoothed. Pass the image itself as the guide
+// for edge-preserving smoothing.
+// See http://kaiminghe.com/eccv10/
+//
+func (im ImageMatrix) GuidedFilter(guide ImageMatrix, radius int, epsilon float64) ImageMatrix {
+	if guide.GetWidth() != im.GetWidth() || guide.GetHeight() != im.GetHeight() {
+		log.Fatalln("The guide image must be the same size as the image being filtered")
+	}
+
+	// everything in the range 0-1 so that epsilon doesn't depend on the bit depth...
+	guideLum := guide.Luminance().scale(1.0 / 255)
+	meanGuide := guideLum.BoxBlur(radius)
+	varGuide := guideLum.multiply(guideLum).BoxBlur(radius).subtract(meanGuide.multiply(meanGuide))
+
+	red, green, blue, alpha := im.splitChannels()
+	filtered := []FloatMatrix{}
+
+	for _, channel := range []FloatMatrix{red, green, blue} {
+		p := channel.scale(1.0 / 255)
+		meanP := p.BoxBlur(radius)
+		covariance := guideLum.multiply(p).BoxBlur(radius).subtract(meanGuide.multiply(meanP))
+
+		// a and b are the straight line (output = a*guide + b) for each window
+		a := NewFloatMatrix(im.GetWidth(), im.GetHeight())
+		b := NewFloatMatrix(im.GetWidth(), im.GetHeight())
+		for x, column := range a {
+			for y := range column {
+				a[x][y] = covariance[x][y] / (varGuide[x][y] + epsilon)
+				b[x][y] = meanP[x][y] - a[x][y]*meanGuide[x][y]
+			}
+		}
+
+		// every pixel is covered by many windows, so use the average line of all of them
+		q := a.BoxBlur(radius).multiply(guideLum).add(b.BoxBlur(radius))
+		filtered = append(filtered, q.scale(255))
+	}
+
+	return mergeChannels(filtered[0], filtered[1], filtered[2], alpha)
+}
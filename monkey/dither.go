@@ -0,0 +1,270 @@
+package monkey
+
+import "image"
+import "image/color"
+import "image/draw"
+import "log"
+import "math"
+
+//
+// ErrorDiffusion is the pattern used to spread the error (the difference between a pixel's colour and the
+// palette colour it was given) onto the pixels that haven't been done yet...
+//
+type ErrorDiffusion int
+
+const (
+	// FloydSteinberg spreads the error over the 4 nearest pixels
+	FloydSteinberg ErrorDiffusion = iota
+
+	// Atkinson spreads only 3/4 of the error (over 6 pixels), which gives more contrast; it's the look of the
+	// original Apple Macintosh
+	Atkinson
+
+	// JarvisJudiceNinke spreads the error over 12 pixels across 3 rows; smoother, but slower
+	JarvisJudiceNinke
+
+	// Sierra is the 3 row Sierra filter (10 pixels), between Floyd-Steinberg and Jarvis-Judice-Ninke
+	Sierra
+)
+
+//
+// diffusionTap is one of the pixels the error is spread to; dx/dy from the current pixel, and the share it gets
+//
+type diffusionTap struct {
+	dx, dy int
+	weight float64
+}
+
+//
+// taps returns where (and how much of) the error goes for the method
+//
+func (method ErrorDiffusion) taps() []diffusionTap {
+	switch method {
+	case FloydSteinberg:
+		return []diffusionTap{
+			{1, 0, 7.0 / 16},
+			{-1, 1, 3.0 / 16}, {0, 1, 5.0 / 16}, {1, 1, 1.0 / 16},
+		}
+	case Atkinson:
+		return []diffusionTap{
+			{1, 0, 1.0 / 8}, {2, 0, 1.0 / 8},
+			{-1, 1, 1.0 / 8}, {0, 1, 1.0 / 8}, {1, 1, 1.0 / 8},
+			{0, 2, 1.0 / 8},
+		}
+	case JarvisJudiceNinke:
+		return []diffusionTap{
+			{1, 0, 7.0 / 48}, {2, 0, 5.0 / 48},
+			{-2, 1, 3.0 / 48}, {-1, 1, 5.0 / 48}, {0, 1, 7.0 / 48}, {1, 1, 5.0 / 48}, {2, 1, 3.0 / 48},
+			{-2, 2, 1.0 / 48}, {-1, 2, 3.0 / 48}, {0, 2, 5.0 / 48}, {1, 2, 3.0 / 48}, {2, 2, 1.0 / 48},
+		}
+	case Sierra:
+		return []diffusionTap{
+			{1, 0, 5.0 / 32}, {2, 0, 3.0 / 32},
+			{-2, 1, 2.0 / 32}, {-1, 1, 4.0 / 32}, {0, 1, 5.0 / 32}, {1, 1, 4.0 / 32}, {2, 1, 2.0 / 32},
+			{-1, 2, 2.0 / 32}, {0, 2, 3.0 / 32}, {1, 2, 2.0 / 32},
+		}
+	}
+
+	log.Fatalln("Unknown error diffusion method:", int(method))
+	return nil
+}
+
+//
+// Dither returns a new ImageMatrix with every pixel set to a colour from the palette, using error diffusion
+// dithering; the pixels are done a row at a time, and whatever error there is in the colour given to a pixel is
+// spread onto the pixels to the right and below it, so that on average the colours come out right.
+//
+// If serpentine is true, every other row is done right to left (and the pattern is flipped to match), which
+// stops the error always being pushed the same way and breaks up the diagonal "worm" artifacts.
+//
+func (im ImageMatrix) Dither(palette color.Palette, method ErrorDiffusion, serpentine bool) ImageMatrix {
+	if len(palette) == 0 {
+		log.Fatalln("Can't dither an image to an empty palette")
+	}
+
+	width := im.GetWidth()
+	height := im.GetHeight()
+	colours := paletteRGBA(palette)
+	taps := method.taps()
+
+	// the channels with the error added in as we go (they can go outside 0-255)
+	r, g, b, a := im.splitChannels()
+	channels := [4]FloatMatrix{r, g, b, a}
+
+	newMatrix := NewImageMatrix(width, height)
+
+	for y := 0; y < height; y++ {
+		reverse := serpentine && y%2 == 1
+
+		for i := 0; i < width; i++ {
+			x, direction := i, 1
+			if reverse {
+				x, direction = width-1-i, -1
+			}
+
+			wanted := premultipliedRGBA(channels[0][x][y], channels[1][x][y], channels[2][x][y], channels[3][x][y])
+			chosen := colours[nearestColourIndex(colours, wanted)]
+			newMatrix[x][y] = chosen
+
+			errors := [4]float64{
+				channels[0][x][y] - float64(chosen.R),
+				channels[1][x][y] - float64(chosen.G),
+				channels[2][x][y] - float64(chosen.B),
+				channels[3][x][y] - float64(chosen.A),
+			}
+
+			for _, tap := range taps {
+				tx, ty := x+tap.dx*direction, y+tap.dy
+				if tx < 0 || tx >= width || ty >= height {
+					continue
+				}
+
+				for c := range channels {
+					channels[c][tx][ty] += errors[c] * tap.weight
+				}
+			}
+		}
+	}
+
+	return newMatrix
+}
+
+//
+// BayerMatrix returns the size x size Bayer (ordered dither) threshold matrix, with the values 0 to size*size-1
+// spread out as evenly as possible. size must be a power of 2 (eg. 2, 4 or 8).
+//
+func BayerMatrix(size int) [][]int {
+	if size < 2 || size&(size-1) != 0 {
+		log.Fatalln("The Bayer matrix size must be a power of 2:", size)
+	}
+
+	matrix := [][]int{{0}}
+
+	// each doubling puts 4 copies of the last matrix together: [4M, 4M+2], [4M+3, 4M+1]
+	for n := 1; n < size; n *= 2 {
+		next := make([][]int, 2*n)
+		for x := range next {
+			next[x] = make([]int, 2*n)
+		}
+
+		for x := 0; x < n; x++ {
+			for y := 0; y < n; y++ {
+				v := 4 * matrix[x][y]
+				next[x][y] = v
+				next[x+n][y] = v + 2
+				next[x][y+n] = v + 3
+				next[x+n][y+n] = v + 1
+			}
+		}
+
+		matrix = next
+	}
+
+	return matrix
+}
+
+//
+// OrderedDither returns a new ImageMatrix with every pixel set to a colour from the palette, using ordered
+// dithering with a size x size Bayer matrix (size is 2, 4 or 8); before each pixel is matched to the palette, it's
+// nudged lighter or darker by an amount from the matrix, tiled over the image. Unlike error diffusion, every
+// pixel is done on it's own, so it gives the regular crosshatch pattern, and small changes to the image don't
+// ripple across it (which is why it's used for animations).
+//
+// The size of the nudge is the average distance between the palette colours, so it's right for both a 2 colour
+// palette and a 256 colour one.
+//
+func (im ImageMatrix) OrderedDither(palette color.Palette, size int) ImageMatrix {
+	if len(palette) == 0 {
+		log.Fatalln("Can't dither an image to an empty palette")
+	}
+
+	bayer := BayerMatrix(size)
+	colours := paletteRGBA(palette)
+	spread := paletteSpread(colours)
+	newMatrix := ImageMatrix{}
+
+	for x, column := range im {
+		newColumn := make(ImageRow, len(column))
+
+		for y, c := range column {
+			// (-0.5 to 0.5, centred on 0 so the image doesn't get lighter or darker overall)
+			nudge := ((float64(bayer[x%size][y%size])+0.5)/float64(size*size) - 0.5) * spread
+			wanted := premultipliedRGBA(float64(c.R)+nudge, float64(c.G)+nudge, float64(c.B)+nudge, float64(c.A))
+			newColumn[y] = colours[nearestColourIndex(colours, wanted)]
+		}
+
+		newMatrix = append(newMatrix, newColumn)
+	}
+
+	return newMatrix
+}
+
+//
+// paletteSpread returns the average (per channel) distance from each palette colour to the nearest other one
+//
+func paletteSpread(colours []color.RGBA) float64 {
+	if len(colours) < 2 {
+		return 0
+	}
+
+	total := 0.0
+	for i, c := range colours {
+		nearest := -1
+		for j, other := range colours {
+			if i != j && (nearest == -1 || colourDistance(c, other) < nearest) {
+				nearest = colourDistance(c, other)
+			}
+		}
+
+		total += math.Sqrt(float64(nearest) / 3)
+	}
+
+	return total / float64(len(colours))
+}
+
+//
+// Ditherer draws an image onto a paletted image with one of our dithering methods. It satisfies draw.Drawer, so
+// it can be handed to the gif encoder (and util's paletted image saving functions) along with a Quantizer.
+//
+type Ditherer struct {
+	// Diffusion and Serpentine are used for error diffusion dithering (see Dither)...
+	Diffusion  ErrorDiffusion
+	Serpentine bool
+
+	// ...unless BayerSize is set, in which case ordered dithering with a Bayer matrix of that size is used
+	BayerSize int
+}
+
+//
+// Draw dithers the part of src starting at sp onto the rectangle r of dst. If dst isn't a paletted image there
+// is nothing to dither to, so src is just copied over.
+//
+func (d Ditherer) Draw(dst draw.Image, r image.Rectangle, src image.Image, sp image.Point) {
+	paletted, ok := dst.(*image.Paletted)
+	if !ok {
+		draw.Draw(dst, r, src, sp, draw.Src)
+		return
+	}
+
+	// (if r is clipped, sp has to move by the same amount, so the same source pixels still land in the same places)
+	clipped := r.Intersect(dst.Bounds())
+	if clipped.Empty() {
+		return
+	}
+
+	sp = sp.Add(clipped.Min.Sub(r.Min))
+	r = clipped
+	matrix := imageRectToImageMatrix(src, r.Sub(r.Min).Add(sp))
+
+	if d.BayerSize > 0 {
+		matrix = matrix.OrderedDither(paletted.Palette, d.BayerSize)
+	} else {
+		matrix = matrix.Dither(paletted.Palette, d.Diffusion, d.Serpentine)
+	}
+
+	for x, column := range matrix {
+		for y, c := range column {
+			paletted.Set(r.Min.X+x, r.Min.Y+y, c)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package monkey
+
+import "encoding/binary"
+import "strings"
+import "time"
+
+//
+// EXIF holds the basic fields from an image's EXIF block (the metadata cameras and phones write into the file).
+// Any field that wasn't in the block is left at it's zero value.
+//
+type EXIF struct {
+	// Orientation says how the picture has to be turned to be the right way up (1-8, see ApplyEXIFOrientation)
+	Orientation int
+
+	// Make and Model are the camera (or phone) that took the picture
+	Make  string
+	Model string
+
+	// CaptureTime is when the picture was taken (the original date/time, or failing that, the modified date/time);
+	// EXIF doesn't say what timezone it's in, so it's treated as UTC
+	CaptureTime time.Time
+
+	// Width and Height are the dimensions of the picture as the camera stored it (before any orientation)
+	Width  int
+	Height int
+}
+
+//
+// The EXIF tags we look for...
+//
+const (
+	exifTagMake             = 0x010F
+	exifTagModel            = 0x0110
+	exifTagOrientation      = 0x0112
+	exifTagDateTime         = 0x0132
+	exifTagExifIFD          = 0x8769
+	exifTagDateTimeOriginal = 0x9003
+	exifTagPixelXDimension  = 0xA002
+	exifTagPixelYDimension  = 0xA003
+)
+
+//
+// exifDateTimeLayout is how EXIF writes dates and times
+//
+const exifDateTimeLayout = "2006:01:02 15:04:05"
+
+//
+// parseEXIF reads the fields we are interested in out of a raw EXIF block (a TIFF header followed by the IFDs).
+// It returns false if the block doesn't make sense.
+//
+func parseEXIF(block []byte) (EXIF, bool) {
+	exif := EXIF{}
+	if len(block) < 8 {
+		return exif, false
+	}
+
+	var order binary.ByteOrder
+	switch string(block[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return exif, false
+	}
+
+	if order.Uint16(block[2:]) != 42 {
+		return exif, false
+	}
+
+	var dateTime, dateTimeOriginal string
+
+	// readIFD goes through the entries of the IFD at offset, handing each tag and it's value(s) to handle
+	readIFD := func(offset uint32, handle func(tag uint16, values []uint32, text string)) {
+		if int(offset)+2 > len(block) {
+			return
+		}
+
+		count := int(order.Uint16(block[offset:]))
+		for n := 0; n < count; n++ {
+			entry := int(offset) + 2 + n*12
+			if entry+12 > len(block) {
+				return
+			}
+
+			tag := order.Uint16(block[entry:])
+			valueType := order.Uint16(block[entry+2:])
+			valueCount := int(order.Uint32(block[entry+4:]))
+
+			size := map[uint16]int{2: 1, 3: 2, 4: 4}[valueType]
+			if size == 0 || valueCount <= 0 || valueCount > len(block) {
+				continue
+			}
+
+			// the value is in the entry itself if it fits in 4 bytes, otherwise the entry holds it's offset
+			value := block[entry+8 : entry+12]
+			if size*valueCount > 4 {
+				valueOffset := int(order.Uint32(value))
+				if valueOffset < 0 || valueOffset+size*valueCount > len(block) {
+					continue
+				}
+
+				value = block[valueOffset : valueOffset+size*valueCount]
+			}
+
+			switch valueType {
+			case 2: // ASCII (nul terminated)
+				text := string(value[:valueCount])
+				handle(tag, nil, strings.TrimSpace(strings.TrimRight(text, "\x00")))
+			case 3: // SHORT
+				handle(tag, []uint32{uint32(order.Uint16(value))}, "")
+			case 4: // LONG
+				handle(tag, []uint32{order.Uint32(value)}, "")
+			}
+		}
+	}
+
+	var exifIFD uint32
+	readIFD(order.Uint32(block[4:]), func(tag uint16, values []uint32, text string) {
+		switch tag {
+		case exifTagMake:
+			exif.Make = text
+		case exifTagModel:
+			exif.Model = text
+		case exifTagOrientation:
+			if len(values) > 0 {
+				exif.Orientation = int(values[0])
+			}
+		case exifTagDateTime:
+			dateTime = text
+		case exifTagExifIFD:
+			if len(values) > 0 {
+				exifIFD = values[0]
+			}
+		}
+	})
+
+	if exifIFD != 0 {
+		readIFD(exifIFD, func(tag uint16, values []uint32, text string) {
+			switch tag {
+			case exifTagDateTimeOriginal:
+				dateTimeOriginal = text
+			case exifTagPixelXDimension:
+				if len(values) > 0 {
+					exif.Width = int(values[0])
+				}
+			case exifTagPixelYDimension:
+				if len(values) > 0 {
+					exif.Height = int(values[0])
+				}
+			}
+		})
+	}
+
+	for _, text := range []string{dateTimeOriginal, dateTime} {
+		if t, err := time.Parse(exifDateTimeLayout, text); err == nil {
+			exif.CaptureTime = t
+			break
+		}
+	}
+
+	return exif, true
+}
+
+//
+// ApplyEXIFOrientation returns a new ImageMatrix turned (and/or flipped) the right way up for the given EXIF
+// orientation:
+//   1 - already the right way up        5 - transposed (flipped along the top left to bottom right diagonal)
+//   2 - flipped horizontally            6 - needs rotating 90 degrees clockwise
+//   3 - upside down (rotated 180)       7 - transversed (flipped along the other diagonal)
+//   4 - flipped vertically              8 - needs rotating 90 degrees anticlockwise
+// Anything else is treated as 1 (and a copy of the ImageMatrix is returned as it is).
+//
+func (im ImageMatrix) ApplyEXIFOrientation(orientation int) ImageMatrix {
+	switch orientation {
+	case 2:
+		return im.FlipHorizontal()
+	case 3:
+		return im.Rotate180()
+	case 4:
+		return im.FlipVertical()
+	case 5:
+		return im.Transpose()
+	case 6:
+		return im.Rotate90()
+	case 7:
+		return im.Rotate90().FlipVertical()
+	case 8:
+		return im.Rotate270()
+	}
+
+	return im.Clone()
+}
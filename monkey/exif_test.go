@@ -0,0 +1,207 @@
+package monkey
+
+import (
+	"encoding/binary"
+	"image/color"
+	"testing"
+	"time"
+)
+
+//
+// testEntry is an IFD entry for buildEXIF; a SHORT or LONG value, or (for ASCII) the text
+//
+type testEntry struct {
+	tag       uint16
+	valueType uint16
+	value     uint32
+	text      string
+}
+
+//
+// buildEXIF returns an EXIF block (a TIFF header, the first IFD, the EXIF IFD if there are any entries for it,
+// and then any text too long to go in it's entry) in the given byte order
+//
+func buildEXIF(order binary.ByteOrder, entries []testEntry, exifEntries []testEntry) []byte {
+	if len(exifEntries) > 0 {
+		exifIFD := 8 + 2 + (len(entries)+1)*12 + 4
+		entries = append(entries, testEntry{tag: exifTagExifIFD, valueType: 4, value: uint32(exifIFD)})
+	}
+
+	block := make([]byte, 8)
+	if order == binary.LittleEndian {
+		copy(block, "II")
+	} else {
+		copy(block, "MM")
+	}
+	order.PutUint16(block[2:], 42)
+	order.PutUint32(block[4:], 8)
+
+	// the text goes after both IFDs
+	dataOffset := 8 + 2 + len(entries)*12 + 4
+	if len(exifEntries) > 0 {
+		dataOffset += 2 + len(exifEntries)*12 + 4
+	}
+	data := []byte{}
+
+	writeIFD := func(entries []testEntry) {
+		ifd := make([]byte, 2+len(entries)*12+4)
+		order.PutUint16(ifd, uint16(len(entries)))
+
+		for n, e := range entries {
+			entry := ifd[2+n*12:]
+			order.PutUint16(entry, e.tag)
+			order.PutUint16(entry[2:], e.valueType)
+			order.PutUint32(entry[4:], 1)
+
+			switch e.valueType {
+			case 2:
+				text := e.text + "\x00"
+				order.PutUint32(entry[4:], uint32(len(text)))
+				if len(text) <= 4 {
+					copy(entry[8:], text)
+				} else {
+					order.PutUint32(entry[8:], uint32(dataOffset+len(data)))
+					data = append(data, text...)
+				}
+			case 3:
+				order.PutUint16(entry[8:], uint16(e.value))
+			default:
+				order.PutUint32(entry[8:], e.value)
+			}
+		}
+
+		block = append(block, ifd...)
+	}
+
+	writeIFD(entries)
+	if len(exifEntries) > 0 {
+		writeIFD(exifEntries)
+	}
+
+	return append(block, data...)
+}
+
+func TestParseEXIF(t *testing.T) {
+	entries := []testEntry{
+		{tag: exifTagMake, valueType: 2, text: "Canon"},
+		{tag: exifTagModel, valueType: 2, text: "EOS"},
+		{tag: exifTagOrientation, valueType: 3, value: 6},
+		{tag: exifTagDateTime, valueType: 2, text: "2021:03:04 05:06:07"},
+	}
+	exifEntries := []testEntry{
+		{tag: exifTagDateTimeOriginal, valueType: 2, text: "2020:01:02 03:04:05"},
+		{tag: exifTagPixelXDimension, valueType: 3, value: 4000},
+		{tag: exifTagPixelYDimension, valueType: 4, value: 3000},
+	}
+	full := EXIF{
+		Orientation: 6,
+		Make:        "Canon",
+		Model:       "EOS",
+		CaptureTime: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Width:       4000,
+		Height:      3000,
+	}
+
+	withoutExifIFD := full
+	withoutExifIFD.Width, withoutExifIFD.Height = 0, 0
+	withoutExifIFD.CaptureTime = time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	withoutMake := full
+	withoutMake.Make = ""
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		block := buildEXIF(order, entries, exifEntries)
+
+		// the first IFD's offset, the EXIF IFD's offset (in the last entry of the first IFD) and the make's text
+		// offset (in the first entry) pointing past the end of the block
+		badIFD := append([]byte{}, block...)
+		order.PutUint32(badIFD[4:], uint32(len(block)))
+		badExifIFD := append([]byte{}, block...)
+		order.PutUint32(badExifIFD[8+2+4*12+8:], 0xFFFFFFF0)
+		badText := append([]byte{}, block...)
+		order.PutUint32(badText[8+2+8:], uint32(len(block)-2))
+
+		tests := []struct {
+			name  string
+			block []byte
+			want  EXIF
+			ok    bool
+		}{
+			{"everything", block, full, true},
+			{"no EXIF IFD", buildEXIF(order, entries, nil), withoutExifIFD, true},
+			{"LONG width, SHORT height", buildEXIF(order, nil, []testEntry{
+				{tag: exifTagPixelXDimension, valueType: 4, value: 70000},
+				{tag: exifTagPixelYDimension, valueType: 3, value: 50},
+			}), EXIF{Width: 70000, Height: 50}, true},
+			{"bad date", buildEXIF(order, []testEntry{{tag: exifTagDateTime, valueType: 2, text: "yesterday"}}, nil),
+				EXIF{}, true},
+			{"first IFD past the end", badIFD, EXIF{}, true},
+			{"EXIF IFD past the end", badExifIFD, withoutExifIFD, true},
+			{"text past the end", badText, withoutMake, true},
+			{"too short", block[:7], EXIF{}, false},
+			{"bad byte order", append([]byte("XX"), block[2:]...), EXIF{}, false},
+			{"bad magic number", append(append([]byte{}, block[:2]...), append([]byte{43, 43}, block[4:]...)...),
+				EXIF{}, false},
+		}
+
+		for _, test := range tests {
+			got, ok := parseEXIF(test.block)
+			if got != test.want || ok != test.ok {
+				t.Errorf("%v %v: got %+v, %v; want %+v, %v", order, test.name, got, ok, test.want, test.ok)
+			}
+		}
+
+		// (however much of the block is missing, parseEXIF mustn't panic)
+		for n := 0; n < len(block); n++ {
+			parseEXIF(block[:n])
+		}
+	}
+}
+
+func TestApplyEXIFOrientation(t *testing.T) {
+	// a 3x2 image with a different colour in every pixel, so any wrong turn or flip shows up
+	width, height := 3, 2
+	stored := NewImageMatrix(width, height)
+	for x := range stored {
+		for y := range stored[x] {
+			stored[x][y] = color.RGBA{uint8(x), uint8(y), 0, 255}
+		}
+	}
+
+	// where each pixel of the image the right way up comes from in the stored image
+	tests := []struct {
+		orientation int
+		from        func(x, y int) (int, int)
+	}{
+		{1, func(x, y int) (int, int) { return x, y }},
+		{2, func(x, y int) (int, int) { return width - 1 - x, y }},
+		{3, func(x, y int) (int, int) { return width - 1 - x, height - 1 - y }},
+		{4, func(x, y int) (int, int) { return x, height - 1 - y }},
+		{5, func(x, y int) (int, int) { return y, x }},
+		{6, func(x, y int) (int, int) { return y, height - 1 - x }},
+		{7, func(x, y int) (int, int) { return width - 1 - y, height - 1 - x }},
+		{8, func(x, y int) (int, int) { return width - 1 - y, x }},
+		{0, func(x, y int) (int, int) { return x, y }},
+		{9, func(x, y int) (int, int) { return x, y }},
+	}
+
+	for _, test := range tests {
+		got := stored.ApplyEXIFOrientation(test.orientation)
+
+		wantWidth, wantHeight := width, height
+		if test.orientation >= 5 && test.orientation <= 8 {
+			wantWidth, wantHeight = height, width
+		}
+
+		want := NewImageMatrix(wantWidth, wantHeight)
+		for x := range want {
+			for y := range want[x] {
+				fromX, fromY := test.from(x, y)
+				want[x][y] = stored[fromX][fromY]
+			}
+		}
+
+		if !got.Equal(want) {
+			t.Errorf("orientation %v: got %v, want %v", test.orientation, got, want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package monkey
+
+import "image/color"
+import "math"
+
+//
+// FloatMatrix is a single channel of values, stored the same way round as an ImageMatrix ([x][y]). We use it for
+// things like the luminance of an image, or a gradient map, where we need more precision than a uint8 gives us.
+//
+type FloatMatrix [][]float64
+
+//
+// NewFloatMatrix returns a width x height FloatMatrix with every entry set to 0
+//
+func NewFloatMatrix(width, height int) FloatMatrix {
+	fm := FloatMatrix{}
+	for x := 0; x < width; x++ {
+		fm = append(fm, make([]float64, height))
+	}
+
+	return fm
+}
+
+//
+// GetWidth returns the width of the matrix
+//
+func (fm FloatMatrix) GetWidth() int {
+	return len(fm)
+}
+
+//
+// GetHeight returns the height of the matrix
+//
+func (fm FloatMatrix) GetHeight() int {
+	return len(fm[0])
+}
+
+//
+// Luminance returns the brightness of every pixel (0-255) using the Rec. 601 weights (the same as the YCbCr
+// conversion JPEGs use)
+//
+func (im ImageMatrix) Luminance() FloatMatrix {
+	fm := NewFloatMatrix(im.GetWidth(), im.GetHeight())
+
+	for x, column := range im {
+		for y, c := range column {
+			fm[x][y] = luminance(c)
+		}
+	}
+
+	return fm
+}
+
+//
+// luminance returns the brightness (0-255) of a single colour
+//
+func luminance(c color.RGBA) float64 {
+	return 0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)
+}
+
+//
+// ImageMatrix returns the matrix as an opaque greyscale image (values are rounded and clamped to 0-255)
+//
+func (fm FloatMatrix) ImageMatrix() ImageMatrix {
+	newMatrix := ImageMatrix{}
+
+	for _, column := range fm {
+		newColumn := make(ImageRow, len(column))
+
+		for y, v := range column {
+			grey := uint8(clampFloat(v, 0, 255) + 0.5)
+			newColumn[y] = color.RGBA{grey, grey, grey, 255}
+		}
+
+		newMatrix = append(newMatrix, newColumn)
+	}
+
+	return newMatrix
+}
+
+//
+// Normalised returns a new FloatMatrix scaled so that the largest value becomes 255 (handy for viewing things
+// like gradient magnitudes, which have a much larger range, as an image)
+//
+func (fm FloatMatrix) Normalised() FloatMatrix {
+	largest := 0.0
+	for _, column := range fm {
+		for _, v := range column {
+			largest = math.Max(largest, v)
+		}
+	}
+
+	newMatrix := fm.copy()
+	if largest == 0 {
+		return newMatrix
+	}
+
+	for _, column := range newMatrix {
+		for y := range column {
+			column[y] = column[y] * 255 / largest
+		}
+	}
+
+	return newMatrix
+}
+
+//
+// GaussianBlur returns a new FloatMatrix blurred with a gaussian of the given standard deviation (sigma). The
+// kernel is cut off at 3 sigma, and the edge values are repeated outwards for the taps outside the matrix.
+//
+func (fm FloatMatrix) GaussianBlur(sigma float64) FloatMatrix {
+	if sigma <= 0 {
+		return fm.copy()
+	}
+
+	return fm.separableConvolve(gaussianKernel(sigma))
+}
+
+//
+// GaussianBlur returns a new ImageMatrix with each channel blurred with a gaussian of the given standard deviation
+// (sigma, in pixels). Unlike the 3x3 GaussianBlurConvolution in the mods, the size of the blur can be anything.
+//
+func (im ImageMatrix) GaussianBlur(sigma float64) ImageMatrix {
+	r, g, b, a := im.splitChannels()
+	return mergeChannels(r.GaussianBlur(sigma), g.GaussianBlur(sigma), b.GaussianBlur(sigma), a.GaussianBlur(sigma))
+}
+
+//
+// BoxBlur returns a new FloatMatrix where every entry is the mean of the (2*radius + 1) square around it (the
+// edge values are repeated outwards for the entries outside the matrix).
+//
+func (fm FloatMatrix) BoxBlur(radius int) FloatMatrix {
+	kernel := make([]float64, 2*radius+1)
+	for i := range kernel {
+		kernel[i] = 1 / float64(len(kernel))
+	}
+
+	return fm.separableConvolve(kernel)
+}
+
+//
+// gaussianKernel returns a normalised 1D gaussian kernel (with an odd number of taps) for the given sigma
+//
+func gaussianKernel(sigma float64) []float64 {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	total := 0.0
+
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-(d * d) / (2 * sigma * sigma))
+		total += kernel[i]
+	}
+
+	for i := range kernel {
+		kernel[i] /= total
+	}
+
+	return kernel
+}
+
+//
+// separableConvolve convolves the matrix with the 1D kernel along x, and then along y
+//
+func (fm FloatMatrix) separableConvolve(kernel []float64) FloatMatrix {
+	width := fm.GetWidth()
+	height := fm.GetHeight()
+	radius := len(kernel) / 2
+
+	horizontal := NewFloatMatrix(width, height)
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			total := 0.0
+			for k, w := range kernel {
+				total += fm[clampInt(x+k-radius, 0, width-1)][y] * w
+			}
+
+			horizontal[x][y] = total
+		}
+	}
+
+	newMatrix := NewFloatMatrix(width, height)
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			total := 0.0
+			for k, w := range kernel {
+				total += horizontal[x][clampInt(y+k-radius, 0, height-1)] * w
+			}
+
+			newMatrix[x][y] = total
+		}
+	}
+
+	return newMatrix
+}
+
+//
+// copy returns a copy of the matrix that doesn't share any of it's memory
+//
+func (fm FloatMatrix) copy() FloatMatrix {
+	newMatrix := FloatMatrix{}
+	for _, column := range fm {
+		newMatrix = append(newMatrix, append([]float64{}, column...))
+	}
+
+	return newMatrix
+}
+
+//
+// scale returns a new FloatMatrix with every entry multiplied by factor
+//
+func (fm FloatMatrix) scale(factor float64) FloatMatrix {
+	newMatrix := NewFloatMatrix(fm.GetWidth(), fm.GetHeight())
+	for x, column := range fm {
+		for y, v := range column {
+			newMatrix[x][y] = v * factor
+		}
+	}
+
+	return newMatrix
+}
+
+//
+// add returns a new FloatMatrix with each entry of other added to the matching entry of fm
+//
+func (fm FloatMatrix) add(other FloatMatrix) FloatMatrix {
+	newMatrix := NewFloatMatrix(fm.GetWidth(), fm.GetHeight())
+	for x, column := range fm {
+		for y, v := range column {
+			newMatrix[x][y] = v + other[x][y]
+		}
+	}
+
+	return newMatrix
+}
+
+//
+// subtract returns a new FloatMatrix with each entry of other taken away from the matching entry of fm
+//
+func (fm FloatMatrix) subtract(other FloatMatrix) FloatMatrix {
+	newMatrix := NewFloatMatrix(fm.GetWidth(), fm.GetHeight())
+	for x, column := range fm {
+		for y, v := range column {
+			newMatrix[x][y] = v - other[x][y]
+		}
+	}
+
+	return newMatrix
+}
+
+//
+// multiply returns a new FloatMatrix with each entry of fm multiplied by the matching entry of other
+//
+func (fm FloatMatrix) multiply(other FloatMatrix) FloatMatrix {
+	newMatrix := NewFloatMatrix(fm.GetWidth(), fm.GetHeight())
+	for x, column := range fm {
+		for y, v := range column {
+			newMatrix[x][y] = v * other[x][y]
+		}
+	}
+
+	return newMatrix
+}
+
+//
+// splitChannels returns the red, green, blue and alpha channels of the image as separate FloatMatrix's
+//
+func (im ImageMatrix) splitChannels() (FloatMatrix, FloatMatrix, FloatMatrix, FloatMatrix) {
+	width := im.GetWidth()
+	height := im.GetHeight()
+	r, g, b, a := NewFloatMatrix(width, height), NewFloatMatrix(width, height), NewFloatMatrix(width, height), NewFloatMatrix(width, height)
+
+	for x, column := range im {
+		for y, c := range column {
+			r[x][y] = float64(c.R)
+			g[x][y] = float64(c.G)
+			b[x][y] = float64(c.B)
+			a[x][y] = float64(c.A)
+		}
+	}
+
+	return r, g, b, a
+}
+
+//
+// mergeChannels puts the red, green, blue and alpha channels back together into an ImageMatrix (the values are
+// rounded and clamped, keeping the colour premultiplied)
+//
+func mergeChannels(r, g, b, a FloatMatrix) ImageMatrix {
+	newMatrix := ImageMatrix{}
+
+	for x := 0; x < r.GetWidth(); x++ {
+		column := make(ImageRow, r.GetHeight())
+		for y := range column {
+			column[y] = premultipliedRGBA(r[x][y], g[x][y], b[x][y], a[x][y])
+		}
+
+		newMatrix = append(newMatrix, column)
+	}
+
+	return newMatrix
+}
+
+//
+// pad returns a new FloatMatrix with border entries added to every side, mirrored from the matrix's edges
+//
+func (fm FloatMatrix) pad(border int) FloatMatrix {
+	width := fm.GetWidth()
+	height := fm.GetHeight()
+	newMatrix := NewFloatMatrix(width+2*border, height+2*border)
+
+	for x, column := range newMatrix {
+		srcX, _ := padSourceIndex(x-border, width, FillMirror)
+
+		for y := range column {
+			srcY, _ := padSourceIndex(y-border, height, FillMirror)
+			column[y] = fm[srcX][srcY]
+		}
+	}
+
+	return newMatrix
+}
@@ -0,0 +1,179 @@
+package monkey
+
+import "log"
+import "math"
+
+//
+// GradientOperator is the pair of 3x3 kernels used to work out how quickly the brightness changes in the x and y
+// directions at each pixel...
+//
+type GradientOperator int
+
+const (
+	// Sobel weights the middle row/column twice as much as the outer ones
+	Sobel GradientOperator = iota
+
+	// Scharr is like Sobel, but with weights that make it closer to rotationally symmetric
+	Scharr
+
+	// Prewitt weights all the rows/columns equally
+	Prewitt
+)
+
+//
+// GradientMap is the result of running a gradient operator over an image. For every pixel, Magnitude is how
+// strong the edge is, and Direction is the angle (in radians, from -Pi to Pi) that the brightness increases in
+// (the edge itself runs at right angles to that).
+//
+type GradientMap struct {
+	Magnitude FloatMatrix
+	Direction FloatMatrix
+}
+
+//
+// kernels returns the x and y kernels for the operator (stored [x][y], like a ConvolutionMatrix)
+//
+func (op GradientOperator) kernels() ([3][3]float64, [3][3]float64) {
+	var side, middle float64
+
+	switch op {
+	case Sobel:
+		side, middle = 1, 2
+	case Scharr:
+		side, middle = 3, 10
+	case Prewitt:
+		side, middle = 1, 1
+	default:
+		log.Fatalln("Unknown gradient operator:", int(op))
+	}
+
+	gx := [3][3]float64{
+		{-side, -middle, -side},
+		{0, 0, 0},
+		{side, middle, side},
+	}
+
+	gy := [3][3]float64{
+		{-side, 0, side},
+		{-middle, 0, middle},
+		{-side, 0, side},
+	}
+
+	return gx, gy
+}
+
+//
+// Gradient returns the gradient map of the image's luminance
+//
+func (im ImageMatrix) Gradient(op GradientOperator) GradientMap {
+	return im.Luminance().Gradient(op)
+}
+
+//
+// Gradient returns the gradient map of the matrix (the edge values are repeated outwards for the taps that fall
+// outside the matrix, so the border of the image doesn't show up as an edge)
+//
+func (fm FloatMatrix) Gradient(op GradientOperator) GradientMap {
+	gxKernel, gyKernel := op.kernels()
+	width := fm.GetWidth()
+	height := fm.GetHeight()
+	gm := GradientMap{NewFloatMatrix(width, height), NewFloatMatrix(width, height)}
+
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			gx, gy := 0.0, 0.0
+
+			for i := 0; i < 3; i++ {
+				for j := 0; j < 3; j++ {
+					v := fm[clampInt(x+i-1, 0, width-1)][clampInt(y+j-1, 0, height-1)]
+					gx += v * gxKernel[i][j]
+					gy += v * gyKernel[i][j]
+				}
+			}
+
+			gm.Magnitude[x][y] = math.Hypot(gx, gy)
+			gm.Direction[x][y] = math.Atan2(gy, gx)
+		}
+	}
+
+	return gm
+}
+
+//
+// Canny returns a mask of the edges in the image, found with the Canny edge detector:
+//   1. the luminance is smoothed with a gaussian blur (sigma) to get rid of noise
+//   2. the Sobel gradient is worked out
+//   3. non-maximum suppression thins the edges down to 1 pixel wide (a pixel is only kept if it's magnitude is
+//      bigger than both of it's neighbours across the edge)
+//   4. the double threshold marks pixels above high as strong edges, and those between low and high as weak
+//   5. hysteresis keeps the weak edge pixels only if they are connected to a strong one
+// low and high are in the same units as the Sobel gradient magnitude (which is about 1020 across a sharp black
+// to white edge); a ratio of 1:2 or 1:3 between them works well.
+// See https://en.wikipedia.org/wiki/Canny_edge_detector
+//
+func (im ImageMatrix) Canny(sigma, low, high float64) BinaryMask {
+	if low > high {
+		log.Fatalln("The low threshold must not be greater than the high threshold:", low, high)
+	}
+
+	gm := im.Luminance().GaussianBlur(sigma).Gradient(Sobel)
+	width := im.GetWidth()
+	height := im.GetHeight()
+
+	// non-maximum suppression...
+	thin := NewFloatMatrix(width, height)
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			m := gm.Magnitude[x][y]
+			if m == 0 {
+				continue
+			}
+
+			// which of the 4 directions (horizontal, the 2 diagonals, vertical) is the gradient closest to?
+			angle := gm.Direction[x][y]
+			sector := int(math.Floor((angle+math.Pi)/(math.Pi/4)+0.5)) % 4
+			dx, dy := [4]int{1, 1, 0, -1}[sector], [4]int{0, 1, 1, 1}[sector]
+
+			before := gm.Magnitude[clampInt(x-dx, 0, width-1)][clampInt(y-dy, 0, height-1)]
+			after := gm.Magnitude[clampInt(x+dx, 0, width-1)][clampInt(y+dy, 0, height-1)]
+
+			// (>= on one side and > on the other, so a plateau 2 pixels wide leaves just one of them)
+			if m >= before && m > after {
+				thin[x][y] = m
+			}
+		}
+	}
+
+	// double threshold and hysteresis; start from every strong pixel and follow the weak pixels connected to it
+	edges := NewBinaryMask(width, height)
+	stack := []Point{}
+
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			if thin[x][y] >= high && !edges[x][y] {
+				edges[x][y] = true
+				stack = append(stack, Point{x, y})
+			}
+
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+
+				for i := p.x - 1; i <= p.x+1; i++ {
+					for j := p.y - 1; j <= p.y+1; j++ {
+						if i < 0 || j < 0 || i >= width || j >= height || edges[i][j] {
+							continue
+						}
+
+						if thin[i][j] >= low {
+							edges[i][j] = true
+							stack = append(stack, Point{i, j})
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return edges
+}
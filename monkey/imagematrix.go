@@ -2,7 +2,6 @@ package monkey
 
 import "image/color"
 import "log"
-import "math"
 
 //
 // Point is a particular pixel position in an image
@@ -25,12 +24,82 @@ type ImageRow []color.RGBA
 //
 // ImageMatrix defines how we store our matrix of colours...
 //
+// The methods (and the mods) never change the ImageMatrix they are called on (or given); they always return a new
+// one that doesn't share any memory with it. The only exceptions are the methods with InPlace in their name,
+// which are there for when the copying would be too slow.
+//
 type ImageMatrix []ImageRow
 
-// ApplyFunctionToEveryPixel applys the given function to every pixel in the image
-// (the function is passed the current pixel colour)
 //
-func (im ImageMatrix) ApplyFunctionToEveryPixel(modFunc func(ImageMatrix, int, int) color.RGBA) {
+// NewImageMatrix returns a width x height ImageMatrix with every pixel set to transparent black
+//
+func NewImageMatrix(width, height int) ImageMatrix {
+	im := ImageMatrix{}
+	for x := 0; x < width; x++ {
+		im = append(im, make(ImageRow, height))
+	}
+
+	return im
+}
+
+//
+// Clone returns a deep copy of the ImageMatrix; it doesn't share any of it's memory with the original, so either
+// can be changed without affecting the other
+//
+func (im ImageMatrix) Clone() ImageMatrix {
+	newMatrix := make(ImageMatrix, 0, len(im))
+	for _, column := range im {
+		newMatrix = append(newMatrix, append(make(ImageRow, 0, len(column)), column...))
+	}
+
+	return newMatrix
+}
+
+//
+// Equal returns true if both ImageMatrix's are the same size and every pixel is the same colour
+//
+func (im ImageMatrix) Equal(other ImageMatrix) bool {
+	if len(im) != len(other) {
+		return false
+	}
+
+	for x, column := range im {
+		if len(column) != len(other[x]) {
+			return false
+		}
+
+		for y, colour := range column {
+			if colour != other[x][y] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+//
+// ApplyFunctionToEveryPixel applys the given function to every pixel in the image, returning the results as a new
+// ImageMatrix (the function is passed the original, unchanged, ImageMatrix and the position of the pixel). See Map
+// for a version that works out the pixels in parallel.
+//
+func (im ImageMatrix) ApplyFunctionToEveryPixel(modFunc func(ImageMatrix, int, int) color.RGBA) ImageMatrix {
+	newMatrix := NewImageMatrix(im.GetWidth(), im.GetHeight())
+	for x, column := range im {
+		for y := range column {
+			newMatrix[x][y] = modFunc(im, x, y)
+		}
+	}
+
+	return newMatrix
+}
+
+//
+// ApplyFunctionToEveryPixelInPlace is ApplyFunctionToEveryPixel without the new ImageMatrix; each result is
+// written straight back into im, so it's quicker, but the function sees the new colour of any pixel it has
+// already been called for
+//
+func (im ImageMatrix) ApplyFunctionToEveryPixelInPlace(modFunc func(ImageMatrix, int, int) color.RGBA) {
 	for x, column := range im {
 		for y := range column {
 			c := modFunc(im, x, y)
@@ -173,197 +242,3 @@ func (im ImageMatrix) GetWidth() int {
 func (im ImageMatrix) GetHeight() int {
 	return len(im[0])
 }
-
-//
-// SeamCarveHorizontal will carve the imagematrix by 1 pixel horizontally
-// *****************************************************************************
-// *****************************************************************************
-// TODO: We need to make this generic and do hozizontal and vertical of course, but for now we have just done
-//       it this way to test it out...
-//       Also, currently we colour the seam pink, but we should delete the seam from the image (carve it,
-//       as "seam carving" implies)
-//       Also, the depth is currently hardcoded, it should be possible to change this - but we are laving
-//       it hardcoded for now because if we enter larger numbers, it can take a long long time to process...
-// *****************************************************************************
-// *****************************************************************************
-//
-func (im ImageMatrix) SeamCarveHorizontal() ImageMatrix {
-	height := im.GetHeight()
-	width := im.GetWidth()
-
-	depth := 3
-	var seam Path
-
-	var startingPathOptions []Path
-	var paths []Path
-
-	// get the starting seam by going through all options that start in the first column (x=0) and getting
-	// the best possible path to start with
-	for j := 0; j < height; j++ {
-		paths = im.GetPathsHorizontal(Path{Point{0, j}}, depth)
-		path := im.GetLowestEnergyPath(paths)
-		startingPathOptions = append(startingPathOptions, path)
-	}
-
-	seam = im.GetLowestEnergyPath(startingPathOptions)
-
-	// while we have not got the end-to-end seam from left to right (till the width of the image), keep adding
-	// to the seam...
-	for len(seam) < width {
-		if len(seam)+depth > width {
-			depth = width - len(seam)
-		}
-
-		paths := im.GetPathsHorizontal(seam, depth)
-		lowestEnergyPath := im.GetLowestEnergyPath(paths)
-		seam = lowestEnergyPath
-	}
-
-	// generate a new image (we don't want to modify the original image)
-	newImage := im
-	for _, point := range seam {
-		newImage[point.x][point.y] = color.RGBA{255, 0, 255, 255}
-	}
-
-	//
-	//
-	//
-	return newImage
-}
-
-//
-// GetEnergyOfPoint returns the "energy" of a pixel - that is, the more different it is from it's surrounding
-// pixels, the higher it's energy
-//
-func (im ImageMatrix) GetEnergyOfPoint(x, y int) float64 {
-	cc := im[x][y] // centre colour (the pixel we are trying to get the enery for)
-
-	kernelMatrix := im.GetKernelMatrix(x, y, 1)
-	numBins := 0 // the number of bins (pixels) we will be comparing our central pixel to...
-	energy := 0.0
-
-	for _, row := range kernelMatrix {
-		for _, c := range row {
-			// ignore points where the RGBA value is 0,0,0,0 as they are probably the ones out of the image
-			// eg. they are the entries above and to the left of 0,0 (this happens because the kernelMatrix
-			// is always a square, so it returns zero'd entries for pixles that don't exist)
-			if c.R == 0 && c.G == 0 && c.B == 0 && c.A == 0 {
-				continue
-			}
-
-			// We should be doing the weight according to the Alpha channel... the close to 0 it is, the
-			// less the energy should be (as we want transparent pixels to not add much energy at all)
-			numBins++
-			energy += math.Abs(math.Abs(float64(cc.R) - float64(c.R)))
-			energy += math.Abs(math.Abs(float64(cc.G) - float64(c.G)))
-			energy += math.Abs(math.Abs(float64(cc.B) - float64(c.B)))
-			energy += math.Abs(math.Abs(float64(cc.A)-float64(c.A))) * 3
-		}
-
-	}
-
-	energy = energy / float64(numBins)
-	// debug("Energy of point:", x, y, energy)
-
-	// log.Printf("Energy=%v, Bins=%v x=%v y=%v", energy, numBins, x, y)
-	// debugPrintMatrix(kernelMatrix)
-	return energy
-}
-
-func (im ImageMatrix) GetPathsHorizontal(path Path, depth int) []Path {
-	// debug("Need to find next path options for:", path)
-	var paths []Path
-	height := im.GetHeight()
-	width := im.GetWidth()
-	x := path[len(path)-1].x
-	y := path[len(path)-1].y
-
-	// debug("x, y", x, y)
-
-	// debug("Path we were passed in is", path)
-
-	if depth == 1 {
-		if x+1 < width {
-			pathWithRightPoint := make(Path, len(path))
-			copy(pathWithRightPoint, path)
-			pathWithRightPoint = append(pathWithRightPoint, Point{x + 1, y})
-			paths = append(paths, pathWithRightPoint)
-		}
-
-		if x+1 < width && y-1 >= 0 {
-			pathWithDiagonalUpPoint := make(Path, len(path))
-			copy(pathWithDiagonalUpPoint, path)
-			pathWithDiagonalUpPoint = append(pathWithDiagonalUpPoint, Point{x + 1, y - 1})
-			paths = append(paths, pathWithDiagonalUpPoint)
-		}
-
-		if x+1 < width && y+1 < height {
-			pathWithDiagonalDownPoint := make(Path, len(path))
-			copy(pathWithDiagonalDownPoint, path)
-			pathWithDiagonalDownPoint = append(pathWithDiagonalDownPoint, Point{x + 1, y + 1})
-			paths = append(paths, pathWithDiagonalDownPoint)
-		}
-	} else if depth > 1 {
-		if x+1 < width {
-			pathWithRightPoint := make(Path, len(path))
-			copy(pathWithRightPoint, path)
-			pathWithRightPoint = append(pathWithRightPoint, Point{x + 1, y})
-			rightPaths := im.GetPathsHorizontal(pathWithRightPoint, depth-1)
-
-			for _, rightPath := range rightPaths {
-				paths = append(paths, rightPath)
-			}
-		}
-
-		if x+1 < width && y-1 >= 0 {
-			pathWithDiagonalUpPoint := make(Path, len(path))
-			copy(pathWithDiagonalUpPoint, path)
-			pathWithDiagonalUpPoint = append(pathWithDiagonalUpPoint, Point{x + 1, y - 1})
-			diagonalUpPaths := im.GetPathsHorizontal(pathWithDiagonalUpPoint, depth-1)
-
-			for _, diagonalUpPath := range diagonalUpPaths {
-				paths = append(paths, diagonalUpPath)
-			}
-		}
-
-		if x+1 < width && y+1 < height {
-			pathWithDiagonalDownPoint := make(Path, len(path))
-			copy(pathWithDiagonalDownPoint, path)
-			pathWithDiagonalDownPoint = append(pathWithDiagonalDownPoint, Point{x + 1, y + 1})
-			diagonalDownPaths := im.GetPathsHorizontal(pathWithDiagonalDownPoint, depth-1)
-
-			for _, diagonalDownPath := range diagonalDownPaths {
-				paths = append(paths, diagonalDownPath)
-			}
-		}
-	}
-
-	return paths
-}
-
-func (im ImageMatrix) GetEnergyOfPath(path Path) float64 {
-	pathEnergy := 0.0
-
-	for _, point := range path {
-		pathEnergy += im.GetEnergyOfPoint(point.x, point.y)
-	}
-
-	return pathEnergy
-}
-
-func (im ImageMatrix) GetLowestEnergyPath(paths []Path) Path {
-	lowestEnergyPath := paths[0]
-	lowestEnergy := im.GetEnergyOfPath(lowestEnergyPath)
-
-	for _, path := range paths {
-		pathEnergy := im.GetEnergyOfPath(path)
-
-		if pathEnergy < lowestEnergy {
-			lowestEnergyPath = path
-			lowestEnergy = pathEnergy
-		}
-
-	}
-
-	return lowestEnergyPath
-}
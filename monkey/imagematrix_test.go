@@ -0,0 +1,159 @@
+package monkey
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"../util"
+)
+
+//
+// testImageMatrix returns a small ImageMatrix with a different (premultiplied) colour in every pixel, some of them
+// partly transparent
+//
+func testImageMatrix() ImageMatrix {
+	im := NewImageMatrix(24, 16)
+
+	for x := range im {
+		for y := range im[x] {
+			a := uint8(255 - (x*y)%96)
+			im[x][y] = color.RGBA{uint8(x*10) % a, uint8(y*15) % a, uint8((x+y)*5) % a, a}
+		}
+	}
+
+	return im
+}
+
+//
+// checkUnchanged runs op on a clone of the test image, and fails the test if op changed it
+//
+func checkUnchanged(t *testing.T, name string, op func(im ImageMatrix)) {
+	t.Helper()
+
+	original := testImageMatrix()
+	input := original.Clone()
+	op(input)
+
+	if !input.Equal(original) {
+		t.Errorf("%v changed the ImageMatrix it was called on", name)
+	}
+}
+
+func TestMethodsLeaveTheImageMatrixAlone(t *testing.T) {
+	se := DiskElement(1)
+	cm := ConvolutionMatrix{{0, 1, 0}, {1, 4, 1}, {0, 1, 0}}
+	corners := [4]FloatPoint{{X: 2, Y: 1}, {X: 21, Y: 3}, {X: 22, Y: 14}, {X: 1, Y: 15}}
+	mask := EllipseMask(24, 16, image.Rect(4, 2, 20, 14))
+	palette := color.Palette{color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255}, color.RGBA{255, 0, 0, 255}}
+	seam := testImageMatrix().FindSeamHorizontal()
+	homography := HomographyToRectangle(corners, 20, 12)
+	blur := func(m ImageMatrix) ImageMatrix { return m.GaussianBlur(1) }
+	invert := func(src ImageMatrix, x, y int) color.RGBA {
+		c := src[x][y]
+		return color.RGBA{c.A - c.R, c.A - c.G, c.A - c.B, c.A}
+	}
+	weights := func(_ ImageMatrix, _, _, _, _ int, c color.RGBA, weight float64) int {
+		return int(c.R) % 3
+	}
+
+	// (everything but ApplyFunctionToEveryPixelInPlace and CropInPlace, which change or share it on purpose)
+	methods := map[string]func(im ImageMatrix){
+		"AdaptiveThreshold":                  func(im ImageMatrix) { im.AdaptiveThreshold(AdaptiveGaussian, 2, 3) },
+		"AffineTransform":                    func(im ImageMatrix) { im.AffineTransform(RotationMatrix(30), Bilinear, color.RGBA{}) },
+		"ApplyConvolution":                   func(im ImageMatrix) { im.ApplyConvolution(cm) },
+		"ApplyConvolutionFunction":           func(im ImageMatrix) { im.ApplyConvolutionFunction(cm, weights) },
+		"ApplyConvolutionThroughMask":        func(im ImageMatrix) { im.ApplyConvolutionThroughMask(cm, mask) },
+		"ApplyConvolutionWithSampleFunction": func(im ImageMatrix) { im.ApplyConvolutionWithSampleFunction(cm) },
+		"ApplyFunctionToEveryPixel":          func(im ImageMatrix) { im.ApplyFunctionToEveryPixel(invert) },
+		"ApplyThroughMask":                   func(im ImageMatrix) { im.ApplyThroughMask(mask, blur) },
+		"BilateralFilter":                    func(im ImageMatrix) { im.BilateralFilter(2, 30) },
+		"BlackHat":                           func(im ImageMatrix) { im.BlackHat(se) },
+		"BlendThroughMask":                   func(im ImageMatrix) { im.BlendThroughMask(im.Rotate180(), mask) },
+		"Bounds":                             func(im ImageMatrix) { im.Bounds() },
+		"Canny":                              func(im ImageMatrix) { im.Canny(1, 10, 30) },
+		"CanvasResize":                       func(im ImageMatrix) { im.CanvasResize(30, 10, AnchorTopLeft, color.RGBA{}) },
+		"Clone":                              func(im ImageMatrix) { im.Clone() },
+		"Close":                              func(im ImageMatrix) { im.Close(se) },
+		"ColourRangeMask":                    func(im ImageMatrix) { im.ColourRangeMask(color.RGBA{100, 100, 100, 255}, 50, 20) },
+		"Crop":                               func(im ImageMatrix) { im.Crop(image.Rect(3, 2, 15, 12)) },
+		"Deskew":                             func(im ImageMatrix) { im.Deskew(corners, 20, 12, Bilinear) },
+		"Dilate":                             func(im ImageMatrix) { im.Dilate(se) },
+		"Dither":                             func(im ImageMatrix) { im.Dither(palette, FloydSteinberg, true) },
+		"Encode":                             func(im ImageMatrix) { im.Encode(&bytes.Buffer{}, util.FormatPNG, nil) },
+		"Equal":                              func(im ImageMatrix) { im.Equal(im.Rotate180()) },
+		"Erode":                              func(im ImageMatrix) { im.Erode(se) },
+		"FindSeamHorizontal":                 func(im ImageMatrix) { im.FindSeamHorizontal() },
+		"FlipHorizontal":                     func(im ImageMatrix) { im.FlipHorizontal() },
+		"FlipVertical":                       func(im ImageMatrix) { im.FlipVertical() },
+		"GaussianBlur":                       func(im ImageMatrix) { im.GaussianBlur(1.5) },
+		"GetHeight":                          func(im ImageMatrix) { im.GetHeight() },
+		"GetKernelMatrix":                    func(im ImageMatrix) { im.GetKernelMatrix(0, 5, 2) },
+		"GetWidth":                           func(im ImageMatrix) { im.GetWidth() },
+		"Gradient":                           func(im ImageMatrix) { im.Gradient(Scharr) },
+		"GuidedFilter":                       func(im ImageMatrix) { im.GuidedFilter(im, 2, 0.01) },
+		"HighPass":                           func(im ImageMatrix) { im.HighPass(2, false) },
+		"HighPassSharpen":                    func(im ImageMatrix) { im.HighPassSharpen(2, 0.5, true) },
+		"KuwaharaFilter":                     func(im ImageMatrix) { im.KuwaharaFilter(2) },
+		"Luminance":                          func(im ImageMatrix) { im.Luminance() },
+		"LuminanceHistogram":                 func(im ImageMatrix) { im.LuminanceHistogram() },
+		"LuminanceMask":                      func(im ImageMatrix) { im.LuminanceMask() },
+		"Map":                                func(im ImageMatrix) { im.Map(invert) },
+		"MapInRect":                          func(im ImageMatrix) { im.MapInRect(image.Rect(2, 2, 10, 10), invert) },
+		"MapThroughMask":                     func(im ImageMatrix) { im.MapThroughMask(mask, invert) },
+		"MapToPalette":                       func(im ImageMatrix) { im.MapToPalette(palette) },
+		"MedianFilter":                       func(im ImageMatrix) { im.MedianFilter(1) },
+		"MorphologicalGradient":              func(im ImageMatrix) { im.MorphologicalGradient(se) },
+		"NonLocalMeans":                      func(im ImageMatrix) { im.NonLocalMeans(1, 3, 10) },
+		"Open":                               func(im ImageMatrix) { im.Open(se) },
+		"OrderedDither":                      func(im ImageMatrix) { im.OrderedDither(palette, 4) },
+		"OtsuThreshold":                      func(im ImageMatrix) { im.OtsuThreshold() },
+		"Pad":                                func(im ImageMatrix) { im.Pad(2, 3, 4, 5, Fill{Mode: FillMirror}) },
+		"PaintSeam":                          func(im ImageMatrix) { im.PaintSeam(seam, SeamColour) },
+		"Palette":                            func(im ImageMatrix) { im.Palette(KMeans, 8) },
+		"PerspectiveTransform":               func(im ImageMatrix) { im.PerspectiveTransform(homography, 20, 12, Bilinear, color.RGBA{}) },
+		"RemoveSeamHorizontal":               func(im ImageMatrix) { im.RemoveSeamHorizontal(seam) },
+		"Resize":                             func(im ImageMatrix) { im.Resize(13, 0, Lanczos3) },
+		"ResizeToFill":                       func(im ImageMatrix) { im.ResizeToFill(10, 10, CatmullRom) },
+		"ResizeToFit":                        func(im ImageMatrix) { im.ResizeToFit(10, 10, Mitchell) },
+		"Rotate":                             func(im ImageMatrix) { im.Rotate(20, Bilinear, color.RGBA{}, RotateCrop) },
+		"Rotate180":                          func(im ImageMatrix) { im.Rotate180() },
+		"Rotate270":                          func(im ImageMatrix) { im.Rotate270() },
+		"Rotate90":                           func(im ImageMatrix) { im.Rotate90() },
+		"SeamCarveHorizontal":                func(im ImageMatrix) { im.SeamCarveHorizontal() },
+		"SeamCarveHorizontalBy":              func(im ImageMatrix) { im.SeamCarveHorizontalBy(3, &Animation{}) },
+		"SeamCarveVertical":                  func(im ImageMatrix) { im.SeamCarveVertical() },
+		"SeamCarveVerticalBy":                func(im ImageMatrix) { im.SeamCarveVerticalBy(3, &Animation{}) },
+		"SeamEnergy":                         func(im ImageMatrix) { im.SeamEnergy() },
+		"Threshold":                          func(im ImageMatrix) { im.Threshold(100) },
+		"TopHat":                             func(im ImageMatrix) { im.TopHat(se) },
+		"Transpose":                          func(im ImageMatrix) { im.Transpose() },
+		"TriangleThreshold":                  func(im ImageMatrix) { im.TriangleThreshold() },
+		"UnsharpMask":                        func(im ImageMatrix) { im.UnsharpMask(1.5, 1, 2, false) },
+		"Composite":                          func(im ImageMatrix) { Composite(im, im.Rotate180(), BlendMultiply, image.Pt(3, 2), 0.5) },
+	}
+
+	for name, op := range methods {
+		checkUnchanged(t, name, op)
+	}
+
+	// (the orientations that don't change anything are the ones most likely to hand back the same ImageMatrix)
+	for orientation := 0; orientation <= 8; orientation++ {
+		checkUnchanged(t, "ApplyEXIFOrientation", func(im ImageMatrix) { im.ApplyEXIFOrientation(orientation) })
+	}
+
+	// and a result that looks like it could be the ImageMatrix itself mustn't share it's pixels either...
+	for name, op := range map[string]func(im ImageMatrix) ImageMatrix{
+		"ApplyEXIFOrientation":  func(im ImageMatrix) ImageMatrix { return im.ApplyEXIFOrientation(1) },
+		"Crop":                  func(im ImageMatrix) ImageMatrix { return im.Crop(im.Bounds()) },
+		"SeamCarveHorizontalBy": func(im ImageMatrix) ImageMatrix { return im.SeamCarveHorizontalBy(0, nil) },
+		"SeamCarveVerticalBy":   func(im ImageMatrix) ImageMatrix { return im.SeamCarveVerticalBy(0, nil) },
+		"MapInRect":             func(im ImageMatrix) ImageMatrix { return im.MapInRect(image.Rectangle{}, invert) },
+	} {
+		checkUnchanged(t, name+" (changing the result)", func(im ImageMatrix) {
+			result := op(im)
+			result[0][0] = color.RGBA{1, 2, 3, 255}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package monkey
+
+import "image"
+import "image/color"
+
+//
+// MapFunction works out the new colour of the pixel at (x, y). It's given the original ImageMatrix, which never
+// changes while the map is running, so it can read any pixel it likes (eg. it's neighbours) and always get the
+// original colour, no matter what order the pixels are worked out in.
+//
+// The pixels are worked out in parallel, so the function must be safe to call from more than one goroutine at a
+// time (which it is, as long as it only reads from anything it shares).
+//
+type MapFunction func(src ImageMatrix, x, y int) color.RGBA
+
+//
+// Map calls fn for every pixel, returning the results as a new ImageMatrix. Unlike ApplyFunctionToEveryPixelInPlace
+// the results are written to the new ImageMatrix, not back into the one fn is reading from.
+//
+func (im ImageMatrix) Map(fn MapFunction) ImageMatrix {
+	return im.mapPixels(im.Bounds(), fn)
+}
+
+//
+// MapInRect is Map for only the pixels inside roi (the region of interest); the pixels outside it are copied
+// across as they are. To map through any other shape, see MapThroughMask.
+//
+func (im ImageMatrix) MapInRect(roi image.Rectangle, fn MapFunction) ImageMatrix {
+	return im.mapPixels(roi, fn)
+}
+
+//
+// mapPixels does the work for the Map methods; a column at a time (in parallel), it copies the column and then
+// overwrites the pixels inside roi with what fn returns for them
+//
+func (im ImageMatrix) mapPixels(roi image.Rectangle, fn MapFunction) ImageMatrix {
+	width := im.GetWidth()
+	roi = roi.Intersect(im.Bounds())
+	newMatrix := make(ImageMatrix, width)
+
+	parallelColumns(width, func(x int) {
+		column := append(make(ImageRow, 0, len(im[x])), im[x]...)
+
+		if x >= roi.Min.X && x < roi.Max.X {
+			for y := roi.Min.Y; y < roi.Max.Y; y++ {
+				column[y] = fn(im, x, y)
+			}
+		}
+
+		newMatrix[x] = column
+	})
+
+	return newMatrix
+}
@@ -0,0 +1,336 @@
+package monkey
+
+import "image"
+import "image/color"
+import "log"
+import "math"
+import "sort"
+
+//
+// Mask is a soft selection (like a selection in GIMP); every pixel has a weight from 0 (not selected at all) to
+// 1 (fully selected), and anything in between is partly selected, so the edges of a selection can be smooth.
+// It's stored the same way round as an ImageMatrix ([x][y]).
+//
+// An operation is applied through a mask by working it out for the whole image and then blending the result
+// with the original image according to the weights (see ApplyThroughMask); so where the weight is 0 the image
+// is left as it was, and where it's 1 it's completely replaced.
+//
+type Mask [][]float64
+
+//
+// polygonSubRows is how many rows (per pixel) PolygonMask samples the polygon at, to get smooth edges
+//
+const polygonSubRows = 4
+
+//
+// NewMask returns a width x height Mask with nothing selected
+//
+func NewMask(width, height int) Mask {
+	return Mask(NewFloatMatrix(width, height))
+}
+
+//
+// GetWidth returns the width of the mask...
+//
+func (m Mask) GetWidth() int {
+	return len(m)
+}
+
+//
+// GetHeight returns the height of the mask...
+//
+func (m Mask) GetHeight() int {
+	if len(m) == 0 {
+		return 0
+	}
+
+	return len(m[0])
+}
+
+//
+// RectangleMask returns a width x height Mask with the pixels inside rect fully selected
+//
+func RectangleMask(width, height int, rect image.Rectangle) Mask {
+	mask := NewMask(width, height)
+	rect = rect.Intersect(image.Rect(0, 0, width, height))
+
+	for x := rect.Min.X; x < rect.Max.X; x++ {
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			mask[x][y] = 1
+		}
+	}
+
+	return mask
+}
+
+//
+// EllipseMask returns a width x height Mask with the ellipse that fits inside rect selected; the edge of the
+// ellipse is anti-aliased (it fades out over about a pixel)
+//
+func EllipseMask(width, height int, rect image.Rectangle) Mask {
+	mask := NewMask(width, height)
+	if rect.Empty() {
+		return mask
+	}
+
+	cx := float64(rect.Min.X+rect.Max.X) / 2
+	cy := float64(rect.Min.Y+rect.Max.Y) / 2
+	rx := float64(rect.Dx()) / 2
+	ry := float64(rect.Dy()) / 2
+
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			// how far the centre of the pixel is from the centre of the ellipse (1 being on the edge)...
+			dx := (float64(x) + 0.5 - cx) / rx
+			dy := (float64(y) + 0.5 - cy) / ry
+			distance := math.Sqrt(dx*dx + dy*dy)
+
+			// ... turned (roughly) into pixels from the edge, so the weight fades from 1 to 0 across it
+			mask[x][y] = clampFloat((1-distance)*math.Min(rx, ry)+0.5, 0, 1)
+		}
+	}
+
+	return mask
+}
+
+//
+// PolygonMask returns a width x height Mask with the inside of the polygon (the points joined up in order, and
+// the last one back to the first) selected. Where the edges cross, the even-odd rule decides what's inside.
+// Each pixel's weight is how much of it the polygon covers, so the edges are anti-aliased.
+//
+func PolygonMask(width, height int, points []image.Point) Mask {
+	mask := NewMask(width, height)
+	if len(points) < 3 {
+		return mask
+	}
+
+	for y := 0; y < height; y++ {
+		for row := 0; row < polygonSubRows; row++ {
+			sy := float64(y) + (float64(row)+0.5)/polygonSubRows
+
+			// find where the edges cross this (sub) row...
+			crossings := []float64{}
+			for i, p1 := range points {
+				p2 := points[(i+1)%len(points)]
+				y1, y2 := float64(p1.Y), float64(p2.Y)
+
+				if (y1 <= sy && sy < y2) || (y2 <= sy && sy < y1) {
+					crossings = append(crossings, float64(p1.X)+(sy-y1)/(y2-y1)*float64(p2.X-p1.X))
+				}
+			}
+
+			sort.Float64s(crossings)
+
+			// ... and fill in between each pair of them, adding how much of each pixel the span covers
+			for i := 0; i+1 < len(crossings); i += 2 {
+				start := math.Max(crossings[i], 0)
+				end := math.Min(crossings[i+1], float64(width))
+
+				for x := int(start); x < width && float64(x) < end; x++ {
+					covered := math.Min(end, float64(x+1)) - math.Max(start, float64(x))
+					mask[x][y] += covered / polygonSubRows
+				}
+			}
+		}
+	}
+
+	return mask
+}
+
+//
+// ColourRangeMask returns a Mask selecting the pixels close to colour. Pixels within tolerance of it (the distance
+// between the colours, treating red, green and blue as 0-255 coordinates) are fully selected, and the selection
+// then fades out over the next softness (0 for a hard edge).
+//
+func (im ImageMatrix) ColourRangeMask(colour color.RGBA, tolerance, softness float64) Mask {
+	mask := NewMask(im.GetWidth(), im.GetHeight())
+
+	for x, column := range im {
+		for y, c := range column {
+			dr := float64(c.R) - float64(colour.R)
+			dg := float64(c.G) - float64(colour.G)
+			db := float64(c.B) - float64(colour.B)
+			distance := math.Sqrt(dr*dr + dg*dg + db*db)
+
+			switch {
+			case distance <= tolerance:
+				mask[x][y] = 1
+			case distance < tolerance+softness:
+				mask[x][y] = 1 - (distance-tolerance)/softness
+			}
+		}
+	}
+
+	return mask
+}
+
+//
+// LuminanceMask returns a Mask where each pixel's weight is it's brightness (so the highlights are selected the
+// most, and the shadows the least); Invert it to select the shadows instead
+//
+func (im ImageMatrix) LuminanceMask() Mask {
+	return Mask(im.Luminance().scale(1.0 / 255))
+}
+
+//
+// Mask returns the BinaryMask as a (hard edged) Mask; set pixels are fully selected
+//
+func (m BinaryMask) Mask() Mask {
+	mask := NewMask(m.GetWidth(), m.GetHeight())
+
+	for x, column := range m {
+		for y, set := range column {
+			if set {
+				mask[x][y] = 1
+			}
+		}
+	}
+
+	return mask
+}
+
+//
+// Invert returns a new Mask with everything that was selected unselected, and vice versa
+//
+func (m Mask) Invert() Mask {
+	newMask := Mask(FloatMatrix(m).copy())
+
+	for _, column := range newMask {
+		for y := range column {
+			column[y] = 1 - column[y]
+		}
+	}
+
+	return newMask
+}
+
+//
+// Feather returns a new Mask with it's edges softened by a gaussian blur of the given standard deviation (sigma,
+// in pixels)
+//
+func (m Mask) Feather(sigma float64) Mask {
+	return Mask(FloatMatrix(m).GaussianBlur(sigma))
+}
+
+//
+// Union returns a new Mask with everything selected in either mask (the larger of the two weights)
+//
+func (m Mask) Union(other Mask) Mask {
+	checkMaskSize(other, len(m), m.GetHeight())
+	newMask := Mask(FloatMatrix(m).copy())
+
+	for x, column := range newMask {
+		for y := range column {
+			column[y] = math.Max(column[y], other[x][y])
+		}
+	}
+
+	return newMask
+}
+
+//
+// Intersect returns a new Mask with only what's selected in both masks (the smaller of the two weights)
+//
+func (m Mask) Intersect(other Mask) Mask {
+	checkMaskSize(other, len(m), m.GetHeight())
+	newMask := Mask(FloatMatrix(m).copy())
+
+	for x, column := range newMask {
+		for y := range column {
+			column[y] = math.Min(column[y], other[x][y])
+		}
+	}
+
+	return newMask
+}
+
+//
+// ImageMatrix returns the mask as an opaque greyscale image (white being fully selected), so it can be seen
+//
+func (m Mask) ImageMatrix() ImageMatrix {
+	return FloatMatrix(m).scale(255).ImageMatrix()
+}
+
+//
+// BlendThroughMask returns a new ImageMatrix with processed (eg. the result of a mod) blended over the image
+// according to the mask; where the weight is 1 it's processed's pixel, where it's 0 it's the image's own pixel,
+// and in between it's a mix of the two. processed and the mask must be the same size as the image.
+//
+func (im ImageMatrix) BlendThroughMask(processed ImageMatrix, mask Mask) ImageMatrix {
+	width := im.GetWidth()
+	height := im.GetHeight()
+
+	if processed.GetWidth() != width || processed.GetHeight() != height {
+		log.Fatalln("Can only blend images of the same size through a mask:", processed.GetWidth(), processed.GetHeight())
+	}
+	checkMaskSize(mask, width, height)
+
+	newMatrix := NewImageMatrix(width, height)
+
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			newMatrix[x][y] = mixColours(im[x][y], processed[x][y], mask[x][y])
+		}
+	}
+
+	return newMatrix
+}
+
+//
+// ApplyThroughMask runs op (any function that takes an ImageMatrix and returns a new one the same size, eg. a
+// method like GaussianBlur, or a mod) on the image, and then blends the result with the image through the mask
+// (see BlendThroughMask), so that only the selected part of the image is changed. For example:
+//   im.ApplyThroughMask(mask, func(m ImageMatrix) ImageMatrix { return m.GaussianBlur(5) })
+//
+func (im ImageMatrix) ApplyThroughMask(mask Mask, op func(ImageMatrix) ImageMatrix) ImageMatrix {
+	checkMaskSize(mask, im.GetWidth(), im.GetHeight())
+	return im.BlendThroughMask(op(im), mask)
+}
+
+//
+// ApplyConvolutionThroughMask is ApplyConvolution, through a mask (see ApplyThroughMask)...
+//
+func (im ImageMatrix) ApplyConvolutionThroughMask(cm ConvolutionMatrix, mask Mask) ImageMatrix {
+	return im.ApplyThroughMask(mask, func(m ImageMatrix) ImageMatrix {
+		return m.ApplyConvolution(cm)
+	})
+}
+
+//
+// MapThroughMask is Map, through a mask (see ApplyThroughMask); fn is only called for the pixels that are
+// selected at all. It's also the way to apply a function to every pixel (as with ApplyFunctionToEveryPixel)
+// through a mask, as a func(ImageMatrix, int, int) color.RGBA can be passed as the MapFunction.
+//
+func (im ImageMatrix) MapThroughMask(mask Mask, fn MapFunction) ImageMatrix {
+	checkMaskSize(mask, im.GetWidth(), im.GetHeight())
+
+	return im.Map(func(src ImageMatrix, x, y int) color.RGBA {
+		if mask[x][y] <= 0 {
+			return src[x][y]
+		}
+
+		return mixColours(src[x][y], fn(src, x, y), mask[x][y])
+	})
+}
+
+//
+// mixColours returns the colour weight of the way from c1 to c2 (as the colours are premultiplied, mixing each
+// channel, including alpha, on it's own gives the right result)
+//
+func mixColours(c1, c2 color.RGBA, weight float64) color.RGBA {
+	weight = clampFloat(weight, 0, 1)
+	mix := func(v1, v2 uint8) uint8 {
+		return uint8(float64(v1) + (float64(v2)-float64(v1))*weight + 0.5)
+	}
+
+	return color.RGBA{mix(c1.R, c2.R), mix(c1.G, c2.G), mix(c1.B, c2.B), mix(c1.A, c2.A)}
+}
+
+//
+// checkMaskSize exits if the mask isn't width x height
+//
+func checkMaskSize(mask Mask, width, height int) {
+	if mask.GetWidth() != width || mask.GetHeight() != height {
+		log.Fatalln("The mask must be the same size as the image:", mask.GetWidth(), mask.GetHeight())
+	}
+}
@@ -1,63 +1,210 @@
 package monkey
 
+import "bytes"
 import "image"
 import _ "image/png"  // The data we are given might be a png file... so need to import image/png to have it's initialisation effects...
 import _ "image/jpeg" // The data we are given might be a jpg file... so need to import image/jpeg to have it's initialisation effects...
-import _ "image/gif"  // The data we are given might be a gif file... so need to import image/gif to have it's initialisation effects...
-import "strings"
+import "image/gif"    // The data we are given might be a gif file... so need to import image/gif to have it's initialisation effects (and for DecodeAll)...
+import "io"
+import "io/ioutil"
 import "../util"
-import "image/color"
+import _ "../formats/netpbm" // The data we are given might be a Netpbm (pbm/pgm/ppm/pam) file...
+import _ "../formats/bmp"    // ... or a bmp
+import _ "../formats/tga"    // ... or a tga
+import _ "../formats/qoi"    // ... or a qoi
 
 //
 // Monkey is our main struct which will have methods we can call on once instantiated...
 //
 type Monkey struct {
-	rawdata string
+	rawdata []byte
+
+	// the decoded (and oriented) rawdata, so that it's only decoded once; ImageMatrix hands out clones of it
+	imageMatrix ImageMatrix
+
+	// (so that the zero value of a Monkey turns images the right way up, this is the opposite of what's set)
+	ignoreOrientation bool
+	stripMetadata     bool
 }
 
 //
-// SetRawData is a exported function so that in just like "LoadImageFromFile" is calling it now,
-// in the future we might call it from imagedata we might already have in memory...
-// TODO: Longer term, i want to write some gimp plugins, and i suspect we can just get the data
-//       from GIMP in memory and return it in memory, so we won't have to use
-//       files / temporary files...
+// Load reads an image from r (eg. a file, a network connection, or the data from a GIMP plugin) and returns a
+// Monkey for it. Unlike the other functions here, it returns an error rather than exiting, including when the
+// data isn't in a format we know about or is corrupt; the whole image is decoded here (and kept for ImageMatrix)
+// so that the later calls don't fail.
 //
-func (i *Monkey) SetRawData(data string) {
+func Load(r io.Reader) (*Monkey, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	monkey := &Monkey{}
+	monkey.SetRawBytes(data)
+
+	if _, err := monkey.decode(); err != nil {
+		return nil, err
+	}
+
+	return monkey, nil
+}
+
+//
+// SetRawBytes sets the image data (the contents of a png, jpg, etc. file) that the other methods work on; it's
+// used as is (not copied), so the slice shouldn't be changed afterwards...
+//
+func (i *Monkey) SetRawBytes(data []byte) {
 	i.rawdata = data
+	i.imageMatrix = nil
 }
 
 //
-// ImageMatrix reads in the rawdata and returns a ImageMatrix
+// SetRawData is the same as SetRawBytes, for data we already have as a string...
 //
-func (i *Monkey) ImageMatrix() ImageMatrix {
-	reader := strings.NewReader(i.rawdata)
-	src, _, err := image.Decode(reader)
+func (i *Monkey) SetRawData(data string) {
+	i.SetRawBytes([]byte(data))
+}
+
+//
+// Format returns the format the image data is in, as reported by the image package (eg. "png", "jpeg", "gif"),
+// which is also what util.Save and Encode take to write it back out in the same format
+//
+func (i *Monkey) Format() util.Format {
+	format, err := i.format()
 	util.CheckError(err)
 
-	bounds := src.Bounds()
-	width, height := bounds.Max.X, bounds.Max.Y
-	imageMatrix := ImageMatrix{}
+	return format
+}
+
+//
+// format is Format, returning the error rather than exiting...
+//
+func (i *Monkey) format() (util.Format, error) {
+	_, format, err := image.DecodeConfig(bytes.NewReader(i.rawdata))
+	return util.Format(format), err
+}
+
+//
+// Encode writes the image (as ImageMatrix returns it) to w in the given format, or in the format it was loaded as
+// if format is empty. The options can be nil; see util.SaveOptions. Like Load, it returns an error (eg. if the
+// image data can't be decoded) rather than exiting.
+//
+func (i *Monkey) Encode(w io.Writer, format util.Format, options *util.SaveOptions) error {
+	if format == "" {
+		var err error
+		if format, err = i.format(); err != nil {
+			return err
+		}
+	}
+
+	imageMatrix, err := i.decode()
+	if err != nil {
+		return err
+	}
+
+	return imageMatrix.Encode(w, format, options)
+}
+
+//
+// SetAutoOrientation sets whether ImageMatrix turns the image the right way up using the EXIF orientation (eg. for
+// photos taken with a phone held sideways). It's on unless this is called with false.
+//
+func (i *Monkey) SetAutoOrientation(enabled bool) {
+	if i.ignoreOrientation == enabled {
+		i.imageMatrix = nil
+	}
+
+	i.ignoreOrientation = !enabled
+}
 
-	for x := bounds.Min.X; x < width; x++ {
-		column := make([]color.RGBA, height)
+//
+// EXIF returns the basic EXIF fields (camera, capture time, dimensions, orientation) of the image, and whether it
+// has an EXIF block at all (only jpegs and pngs are looked at)
+//
+func (i *Monkey) EXIF() (EXIF, bool) {
+	block := util.ReadMetadata(i.rawdata).EXIF
+	if block == nil {
+		return EXIF{}, false
+	}
 
-		for y := bounds.Min.Y; y < height; y++ {
-			colour := src.At(x, y)
-			// column[y] = colour
+	return parseEXIF(block)
+}
 
-			// Doing the below as JPG's usually have a color.YCbCr model, and we want
-			// to keep things in RGBA for simplicity of code... for now :)
-			r, g, b, a := colour.RGBA()
+//
+// SetStripMetadata sets whether Metadata should leave out everything that could say something about the
+// photographer (see util.Metadata's Stripped), eg. before putting the processed image on the web
+//
+func (i *Monkey) SetStripMetadata(strip bool) {
+	i.stripMetadata = strip
+}
 
-			// right shift the values by 8 bits as colour.RGBA() will return a uint32, and we want to keep the most
-			// significant 8 bits NOT the least significant 8 bits
-			column[y] = color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+//
+// Metadata returns the metadata (EXIF, XMP, ICC profile and text) of the image, ready to hand to the save
+// functions in util so that it's kept in the processed image. If ImageMatrix turns the image the right way up,
+// the EXIF orientation is reset to match.
+//
+func (i *Monkey) Metadata() util.Metadata {
+	metadata := util.ReadMetadata(i.rawdata)
+
+	if i.stripMetadata {
+		metadata = metadata.Stripped()
+	}
+
+	if !i.ignoreOrientation {
+		metadata = metadata.WithOrientationReset()
+	}
+
+	return metadata
+}
+
+//
+// ImageMatrix reads in the rawdata and returns a ImageMatrix (turned the right way up if it has an EXIF
+// orientation, unless that's been turned off with SetAutoOrientation). The rawdata is only decoded the first
+// time; every call returns it's own Clone of the result, so it's safe for the caller (or a mod) to change it.
+//
+func (i *Monkey) ImageMatrix() ImageMatrix {
+	imageMatrix, err := i.decode()
+	util.CheckError(err)
+
+	return imageMatrix.Clone()
+}
+
+//
+// decode returns the decoded (and oriented) rawdata, decoding it the first time it's called; it's shared, so it
+// mustn't be changed (ImageMatrix clones it)
+//
+func (i *Monkey) decode() (ImageMatrix, error) {
+	if i.imageMatrix == nil {
+		src, _, err := image.Decode(bytes.NewReader(i.rawdata))
+		if err != nil {
+			return nil, err
+		}
+
+		imageMatrix := ImageToImageMatrix(src)
+
+		if exif, ok := i.EXIF(); ok && !i.ignoreOrientation {
+			imageMatrix = imageMatrix.ApplyEXIFOrientation(exif.Orientation)
 		}
 
-		imageMatrix = append(imageMatrix, column)
+		// debugPrintMatrix(imageMatrix)
+
+		i.imageMatrix = imageMatrix
 	}
 
-	// debugPrintMatrix(imageMatrix)
+	return i.imageMatrix, nil
+}
+
+//
+// Animation reads in the rawdata and returns all of it's frames as an Animation. Only gifs can hold more than one
+// frame; anything else comes back as an Animation with a single frame (the same as ImageMatrix).
+//
+func (i *Monkey) Animation() Animation {
+	if i.Format() != util.FormatGIF {
+		return Animation{Frames: []Frame{{Matrix: i.ImageMatrix()}}}
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(i.rawdata))
+	util.CheckError(err)
 
-	return imageMatrix
+	return AnimationFromGIF(g)
 }
@@ -0,0 +1,421 @@
+package monkey
+
+import "image/color"
+import "log"
+
+//
+// StructuringElement is the "shape" used by the morphological operations (erode, dilate, etc...). Just like a
+// ConvolutionMatrix it must be a square with an odd number of rows/cols, and it's centred on the pixel we are
+// working out. The pixels where the element is true are the neighbours we look at.
+//
+// It's stored the same way round as an ImageMatrix ([x][y]), so you can write your own custom shapes as a
+// literal, eg. StructuringElement{{false, true, false}, {true, true, true}, {false, true, false}}
+//
+type StructuringElement [][]bool
+
+//
+// BinaryMask is a matrix of on/off values the same shape as an ImageMatrix ([x][y]); eg. the result of
+// thresholding an image, or a selection of which pixels to protect when seam carving...
+//
+type BinaryMask [][]bool
+
+//
+// SquareElement returns a (2*radius + 1) square structuring element with every entry set
+//
+func SquareElement(radius int) StructuringElement {
+	size := 2*radius + 1
+	se := StructuringElement{}
+
+	for x := 0; x < size; x++ {
+		column := make([]bool, size)
+		for y := range column {
+			column[y] = true
+		}
+
+		se = append(se, column)
+	}
+
+	return se
+}
+
+//
+// CrossElement returns a (2*radius + 1) square structuring element with just the middle row and column set (a plus sign)
+//
+func CrossElement(radius int) StructuringElement {
+	size := 2*radius + 1
+	se := StructuringElement{}
+
+	for x := 0; x < size; x++ {
+		column := make([]bool, size)
+		for y := range column {
+			column[y] = x == radius || y == radius
+		}
+
+		se = append(se, column)
+	}
+
+	return se
+}
+
+//
+// DiskElement returns a (2*radius + 1) square structuring element with the entries inside a circle of the given
+// radius set
+//
+func DiskElement(radius int) StructuringElement {
+	size := 2*radius + 1
+	se := StructuringElement{}
+
+	for x := 0; x < size; x++ {
+		column := make([]bool, size)
+		for y := range column {
+			dx := x - radius
+			dy := y - radius
+			column[y] = dx*dx+dy*dy <= radius*radius
+		}
+
+		se = append(se, column)
+	}
+
+	return se
+}
+
+//
+// offsets returns the positions (relative to the centre) of all the entries in the element that are set
+//
+func (se StructuringElement) offsets() []Point {
+	width := len(se)
+	if width == 0 || width%2 == 0 || len(se[0]) != width {
+		log.Fatalln("The structuring element must be a square with an odd number of rows/cols")
+	}
+
+	radius := width / 2
+	points := []Point{}
+
+	for x, column := range se {
+		for y, set := range column {
+			if set {
+				points = append(points, Point{x - radius, y - radius})
+			}
+		}
+	}
+
+	return points
+}
+
+//
+// NewBinaryMask returns a width x height BinaryMask with every entry off
+//
+func NewBinaryMask(width, height int) BinaryMask {
+	mask := BinaryMask{}
+	for x := 0; x < width; x++ {
+		mask = append(mask, make([]bool, height))
+	}
+
+	return mask
+}
+
+//
+// GetWidth returns the width of the mask
+//
+func (m BinaryMask) GetWidth() int {
+	return len(m)
+}
+
+//
+// GetHeight returns the height of the mask
+//
+func (m BinaryMask) GetHeight() int {
+	return len(m[0])
+}
+
+//
+// ImageMatrix returns the mask as a black (off) and white (on) image
+//
+func (m BinaryMask) ImageMatrix() ImageMatrix {
+	white := color.RGBA{255, 255, 255, 255}
+	black := color.RGBA{0, 0, 0, 255}
+	newMatrix := ImageMatrix{}
+
+	for _, column := range m {
+		newColumn := make(ImageRow, len(column))
+
+		for y, set := range column {
+			if set {
+				newColumn[y] = white
+			} else {
+				newColumn[y] = black
+			}
+		}
+
+		newMatrix = append(newMatrix, newColumn)
+	}
+
+	return newMatrix
+}
+
+//
+// Erode returns a new mask where a pixel is only on if every pixel under the structuring element (centred on it)
+// is on. It shrinks shapes and removes anything smaller than the element. Pixels outside the mask don't count.
+//
+func (m BinaryMask) Erode(se StructuringElement) BinaryMask {
+	return m.morph(se, true)
+}
+
+//
+// Dilate returns a new mask where a pixel is on if any pixel under the structuring element (centred on it)
+// is on. It grows shapes and fills in gaps smaller than the element.
+//
+func (m BinaryMask) Dilate(se StructuringElement) BinaryMask {
+	return m.morph(se, false)
+}
+
+//
+// Open erodes and then dilates the mask; it removes specks smaller than the structuring element
+//
+func (m BinaryMask) Open(se StructuringElement) BinaryMask {
+	return m.Erode(se).Dilate(se)
+}
+
+//
+// Close dilates and then erodes the mask; it fills in holes smaller than the structuring element
+//
+func (m BinaryMask) Close(se StructuringElement) BinaryMask {
+	return m.Dilate(se).Erode(se)
+}
+
+//
+// Gradient returns the outlines of the shapes in the mask (the dilation minus the erosion)
+//
+func (m BinaryMask) Gradient(se StructuringElement) BinaryMask {
+	return m.Dilate(se).and(m.Erode(se), true)
+}
+
+//
+// TopHat returns the parts of the mask that are smaller than the structuring element (the mask minus it's opening)
+//
+func (m BinaryMask) TopHat(se StructuringElement) BinaryMask {
+	return m.and(m.Open(se), true)
+}
+
+//
+// BlackHat returns the holes in the mask that are smaller than the structuring element (the closing minus the mask)
+//
+func (m BinaryMask) BlackHat(se StructuringElement) BinaryMask {
+	return m.Close(se).and(m, true)
+}
+
+//
+// HitOrMiss returns a mask with the pixels on where the hit element matches only pixels that are on, and the
+// miss element matches only pixels that are off (pixels outside the mask count as off). It's used to find
+// particular patterns, eg. corners or isolated pixels.
+//
+func (m BinaryMask) HitOrMiss(hit, miss StructuringElement) BinaryMask {
+	hitOffsets := hit.offsets()
+	missOffsets := miss.offsets()
+	width := m.GetWidth()
+	height := m.GetHeight()
+	newMask := NewBinaryMask(width, height)
+
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			matched := true
+
+			for _, offset := range hitOffsets {
+				if !m.isSet(x+offset.x, y+offset.y) {
+					matched = false
+					break
+				}
+			}
+
+			for _, offset := range missOffsets {
+				if !matched {
+					break
+				}
+
+				if m.isSet(x+offset.x, y+offset.y) {
+					matched = false
+				}
+			}
+
+			newMask[x][y] = matched
+		}
+	}
+
+	return newMask
+}
+
+//
+// isSet returns true if (x, y) is inside the mask and on
+//
+func (m BinaryMask) isSet(x, y int) bool {
+	return x >= 0 && y >= 0 && x < m.GetWidth() && y < m.GetHeight() && m[x][y]
+}
+
+//
+// morph does the erosion (if erode is true) or dilation of the mask with the structuring element
+//
+func (m BinaryMask) morph(se StructuringElement, erode bool) BinaryMask {
+	offsets := se.offsets()
+	width := m.GetWidth()
+	height := m.GetHeight()
+	newMask := NewBinaryMask(width, height)
+
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			result := erode
+
+			for _, offset := range offsets {
+				i := x + offset.x
+				j := y + offset.y
+
+				if i < 0 || j < 0 || i >= width || j >= height {
+					continue
+				}
+
+				if m[i][j] != erode {
+					result = !erode
+					break
+				}
+			}
+
+			newMask[x][y] = result
+		}
+	}
+
+	return newMask
+}
+
+//
+// and returns a new mask that is on where both m and n (or "not n" if invert is true) are on
+//
+func (m BinaryMask) and(n BinaryMask, invert bool) BinaryMask {
+	newMask := NewBinaryMask(m.GetWidth(), m.GetHeight())
+
+	for x, column := range m {
+		for y, set := range column {
+			newMask[x][y] = set && (n[x][y] != invert)
+		}
+	}
+
+	return newMask
+}
+
+//
+// Erode returns a new ImageMatrix where each colour channel of a pixel is set to the lowest value of that channel
+// under the structuring element; it darkens the image and shrinks bright areas. The alpha is left alone.
+//
+func (im ImageMatrix) Erode(se StructuringElement) ImageMatrix {
+	return im.morph(se, true)
+}
+
+//
+// Dilate returns a new ImageMatrix where each colour channel of a pixel is set to the highest value of that
+// channel under the structuring element; it brightens the image and grows bright areas. The alpha is left alone.
+//
+func (im ImageMatrix) Dilate(se StructuringElement) ImageMatrix {
+	return im.morph(se, false)
+}
+
+//
+// Open erodes and then dilates the image; it removes bright details smaller than the structuring element
+//
+func (im ImageMatrix) Open(se StructuringElement) ImageMatrix {
+	return im.Erode(se).Dilate(se)
+}
+
+//
+// Close dilates and then erodes the image; it removes dark details smaller than the structuring element
+//
+func (im ImageMatrix) Close(se StructuringElement) ImageMatrix {
+	return im.Dilate(se).Erode(se)
+}
+
+//
+// MorphologicalGradient returns the dilation minus the erosion, which highlights the edges in the image
+//
+func (im ImageMatrix) MorphologicalGradient(se StructuringElement) ImageMatrix {
+	return subtractChannels(im.Dilate(se), im.Erode(se), im)
+}
+
+//
+// TopHat returns the image minus it's opening; the bright details smaller than the structuring element
+//
+func (im ImageMatrix) TopHat(se StructuringElement) ImageMatrix {
+	return subtractChannels(im, im.Open(se), im)
+}
+
+//
+// BlackHat returns the closing minus the image; the dark details smaller than the structuring element
+//
+func (im ImageMatrix) BlackHat(se StructuringElement) ImageMatrix {
+	return subtractChannels(im.Close(se), im, im)
+}
+
+//
+// morph does the (greyscale / colour) erosion (if erode is true) or dilation of the image with the structuring
+// element, working on each of the red, green and blue channels separately
+//
+func (im ImageMatrix) morph(se StructuringElement, erode bool) ImageMatrix {
+	offsets := se.offsets()
+	width := im.GetWidth()
+	height := im.GetHeight()
+	newMatrix := ImageMatrix{}
+
+	for x := 0; x < width; x++ {
+		column := make(ImageRow, height)
+
+		for y := 0; y < height; y++ {
+			c := im[x][y]
+
+			// (start from the identity of min/max, not the pixel itself, as the element might leave the centre out)
+			r, g, b := uint8(0), uint8(0), uint8(0)
+			if erode {
+				r, g, b = 255, 255, 255
+			}
+
+			for _, offset := range offsets {
+				i := x + offset.x
+				j := y + offset.y
+
+				if i < 0 || j < 0 || i >= width || j >= height {
+					continue
+				}
+
+				n := im[i][j]
+				if erode {
+					r, g, b = minUint8(r, n.R), minUint8(g, n.G), minUint8(b, n.B)
+				} else {
+					r, g, b = maxUint8(r, n.R), maxUint8(g, n.G), maxUint8(b, n.B)
+				}
+			}
+
+			// (keep the colour premultiplied; it can't be brighter than the pixel's alpha)
+			column[y] = color.RGBA{minUint8(r, c.A), minUint8(g, c.A), minUint8(b, c.A), c.A}
+		}
+
+		newMatrix = append(newMatrix, column)
+	}
+
+	return newMatrix
+}
+
+//
+// subtractChannels returns a - b (for each of the red, green and blue channels, clamped at 0) with the alpha
+// taken from alpha
+//
+func subtractChannels(a, b, alpha ImageMatrix) ImageMatrix {
+	newMatrix := ImageMatrix{}
+
+	for x, column := range a {
+		newColumn := make(ImageRow, len(column))
+
+		for y, c := range column {
+			d := b[x][y]
+			newColumn[y] = color.RGBA{c.R - minUint8(c.R, d.R), c.G - minUint8(c.G, d.G), c.B - minUint8(c.B, d.B), alpha[x][y].A}
+		}
+
+		newMatrix = append(newMatrix, newColumn)
+	}
+
+	return newMatrix
+}
@@ -0,0 +1,91 @@
+package monkey
+
+import "log"
+import "math"
+
+//
+// NonLocalMeans returns a new ImageMatrix denoised with the non-local means algorithm. Rather than averaging a
+// pixel with it's neighbours, we average it with every pixel in the search window around it, weighted by how
+// similar the patch around each of those pixels is to the patch around our pixel. Repeating textures and edges
+// are found all over an image, so the noise averages away while the detail is kept.
+//
+//   patchRadius  - the size of the patches we compare ((2*patchRadius + 1) square, eg. 1-3)
+//   searchRadius - how far away we look for similar patches ((2*searchRadius + 1) square, eg. 5-10)
+//   h            - the filtering strength (in 0-255 colour units); roughly the amount of noise in the image
+//
+// It's slow (the work grows with the square of both radii), so the columns are worked out in parallel.
+// See https://en.wikipedia.org/wiki/Non-local_means
+//
+func (im ImageMatrix) NonLocalMeans(patchRadius, searchRadius int, h float64) ImageMatrix {
+	if patchRadius < 0 || searchRadius < 1 || h <= 0 {
+		log.Fatalln("Invalid non-local means parameters:", patchRadius, searchRadius, h)
+	}
+
+	width := im.GetWidth()
+	height := im.GetHeight()
+	red, green, blue, alpha := im.splitChannels()
+
+	// pad the channels so the patches never need bounds checks (the edges are mirrored outwards)
+	border := patchRadius + searchRadius
+	channels := [3]FloatMatrix{}
+	for i, channel := range []FloatMatrix{red, green, blue} {
+		channels[i] = channel.pad(border)
+	}
+
+	patchSize := float64((2*patchRadius + 1) * (2*patchRadius + 1) * 3)
+	newRed := NewFloatMatrix(width, height)
+	newGreen := NewFloatMatrix(width, height)
+	newBlue := NewFloatMatrix(width, height)
+
+	parallelColumns(width, func(x int) {
+		px := x + border
+
+		for y := 0; y < height; y++ {
+			py := y + border
+			var r, g, b, total, largest float64
+
+			for i := px - searchRadius; i <= px+searchRadius; i++ {
+				for j := py - searchRadius; j <= py+searchRadius; j++ {
+					if i == px && j == py {
+						continue
+					}
+
+					// the mean squared difference between the two patches...
+					distance := 0.0
+					for dx := -patchRadius; dx <= patchRadius; dx++ {
+						for dy := -patchRadius; dy <= patchRadius; dy++ {
+							for _, channel := range channels {
+								d := channel[px+dx][py+dy] - channel[i+dx][j+dy]
+								distance += d * d
+							}
+						}
+					}
+
+					w := math.Exp(-distance / patchSize / (h * h))
+					r += channels[0][i][j] * w
+					g += channels[1][i][j] * w
+					b += channels[2][i][j] * w
+					total += w
+					largest = math.Max(largest, w)
+				}
+			}
+
+			// the pixel's own patch would always be a perfect match, which would swamp the others, so it's given
+			// the same weight as the best match we found instead
+			r += channels[0][px][py] * largest
+			g += channels[1][px][py] * largest
+			b += channels[2][px][py] * largest
+			total += largest
+
+			if total == 0 {
+				r, g, b, total = channels[0][px][py], channels[1][px][py], channels[2][px][py], 1
+			}
+
+			newRed[x][y] = r / total
+			newGreen[x][y] = g / total
+			newBlue[x][y] = b / total
+		}
+	})
+
+	return mergeChannels(newRed, newGreen, newBlue, alpha)
+}
@@ -0,0 +1,488 @@
+package monkey
+
+import "image"
+import "image/color"
+import "log"
+import "sort"
+
+//
+// PaletteMethod is the algorithm used to pick the colours of a palette for an image...
+//
+type PaletteMethod int
+
+const (
+	// MedianCut puts all the colours in a box, and keeps splitting the box with the widest range of a channel in
+	// two (at the median of that channel) until there are enough boxes; each box becomes the average of it's colours
+	MedianCut PaletteMethod = iota
+
+	// Octree builds a tree of the colours (one level per bit, most significant first), and merges the leaves
+	// that are used the least into their parent until there are few enough leaves
+	Octree
+
+	// KMeans starts from the median cut palette and refines it with k-means clustering (each colour is assigned
+	// to it's nearest palette entry, and each entry moved to the average of it's colours, until nothing changes)
+	KMeans
+)
+
+//
+// kMeansIterations is the most times the k-means clustering will go round before giving up on it settling down
+//
+const kMeansIterations = 16
+
+//
+// String returns the name of the method (handy for filenames, etc)
+//
+func (method PaletteMethod) String() string {
+	switch method {
+	case MedianCut:
+		return "MedianCut"
+	case Octree:
+		return "Octree"
+	case KMeans:
+		return "KMeans"
+	}
+
+	return "Unknown"
+}
+
+//
+// colourCount is one of the distinct colours of an image, and how many pixels have it
+//
+type colourCount struct {
+	colour color.RGBA
+	count  int
+}
+
+//
+// Palette returns a palette of (at most) numColours colours that best represents the image, picked with the
+// given method. If the image has fewer distinct colours than that, they are all returned. The alpha is treated
+// like any other channel, so transparent areas get their own entries.
+//
+func (im ImageMatrix) Palette(method PaletteMethod, numColours int) color.Palette {
+	if numColours < 1 {
+		log.Fatalln("A palette needs at least 1 colour:", numColours)
+	}
+
+	colours := im.colourCounts()
+	if len(colours) <= numColours {
+		palette := color.Palette{}
+		for _, cc := range colours {
+			palette = append(palette, cc.colour)
+		}
+
+		return palette
+	}
+
+	switch method {
+	case MedianCut:
+		return medianCut(colours, numColours)
+	case Octree:
+		return octree(colours, numColours)
+	case KMeans:
+		return kMeans(colours, medianCut(colours, numColours))
+	}
+
+	log.Fatalln("Unknown palette method:", int(method))
+	return nil
+}
+
+//
+// MapToPalette returns a new ImageMatrix with every pixel replaced by the nearest colour in the palette (no
+// dithering is done)
+//
+func (im ImageMatrix) MapToPalette(palette color.Palette) ImageMatrix {
+	if len(palette) == 0 {
+		log.Fatalln("Can't map an image to an empty palette")
+	}
+
+	colours := paletteRGBA(palette)
+	nearest := map[color.RGBA]color.RGBA{}
+	newMatrix := ImageMatrix{}
+
+	for _, column := range im {
+		newColumn := make(ImageRow, len(column))
+
+		for y, c := range column {
+			// images tend to have the same colours over and over, so remember the ones we've already looked up
+			mapped, ok := nearest[c]
+			if !ok {
+				mapped = colours[nearestColourIndex(colours, c)]
+				nearest[c] = mapped
+			}
+
+			newColumn[y] = mapped
+		}
+
+		newMatrix = append(newMatrix, newColumn)
+	}
+
+	return newMatrix
+}
+
+//
+// Quantizer picks the palette for an image using one of our palette methods. It satisfies draw.Quantizer, so it
+// can be handed to the gif encoder (and util's paletted image saving functions) in place of the default
+// (Plan 9) palette.
+//
+type Quantizer struct {
+	Method PaletteMethod
+}
+
+//
+// Quantize appends (at most) cap(p) - len(p) colours picked for the image m to p
+//
+func (q Quantizer) Quantize(p color.Palette, m image.Image) color.Palette {
+	numColours := cap(p) - len(p)
+	if numColours < 1 {
+		return p
+	}
+
+	return append(p, ImageToImageMatrix(m).Palette(q.Method, numColours)...)
+}
+
+//
+// colourCounts returns the distinct colours in the image with how often each is used (sorted, so that the
+// palettes we build from them always come out the same)
+//
+func (im ImageMatrix) colourCounts() []colourCount {
+	counts := map[color.RGBA]int{}
+	for _, column := range im {
+		for _, c := range column {
+			counts[c]++
+		}
+	}
+
+	colours := make([]colourCount, 0, len(counts))
+	for c, n := range counts {
+		colours = append(colours, colourCount{c, n})
+	}
+
+	sort.Slice(colours, func(i, j int) bool {
+		return packRGBA(colours[i].colour) < packRGBA(colours[j].colour)
+	})
+
+	return colours
+}
+
+//
+// medianCut returns a palette of numColours colours built with the median cut algorithm
+//
+func medianCut(colours []colourCount, numColours int) color.Palette {
+	boxes := [][]colourCount{colours}
+
+	for len(boxes) < numColours {
+		// find the box (that can still be split) with the widest range in any one channel...
+		best, bestChannel, bestRange := -1, 0, -1
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+
+			channel, size := widestChannel(box)
+			if size > bestRange {
+				best, bestChannel, bestRange = i, channel, size
+			}
+		}
+
+		if best == -1 {
+			break
+		}
+
+		// ...sort it along that channel and split it where half the pixels are on each side
+		box := boxes[best]
+		sort.SliceStable(box, func(i, j int) bool {
+			return channelValue(box[i].colour, bestChannel) < channelValue(box[j].colour, bestChannel)
+		})
+
+		total := 0
+		for _, cc := range box {
+			total += cc.count
+		}
+
+		split, running := 1, box[0].count
+		for split < len(box)-1 && running < total/2 {
+			running += box[split].count
+			split++
+		}
+
+		boxes[best] = box[:split]
+		boxes = append(boxes, box[split:])
+	}
+
+	palette := color.Palette{}
+	for _, box := range boxes {
+		palette = append(palette, averageColour(box))
+	}
+
+	return palette
+}
+
+//
+// widestChannel returns which channel (0-3 for R, G, B, A) has the widest range of values in the box, and the
+// size of that range
+//
+func widestChannel(box []colourCount) (int, int) {
+	lowest := [4]uint8{255, 255, 255, 255}
+	highest := [4]uint8{}
+
+	for _, cc := range box {
+		for channel := 0; channel < 4; channel++ {
+			v := channelValue(cc.colour, channel)
+			lowest[channel] = minUint8(lowest[channel], v)
+			highest[channel] = maxUint8(highest[channel], v)
+		}
+	}
+
+	widest, size := 0, -1
+	for channel := 0; channel < 4; channel++ {
+		if int(highest[channel])-int(lowest[channel]) > size {
+			widest, size = channel, int(highest[channel])-int(lowest[channel])
+		}
+	}
+
+	return widest, size
+}
+
+//
+// octreeNode is a node in the octree. It's really a 16 way tree, as we use a bit of the alpha as well as the red,
+// green and blue to pick the child, so that transparent pixels don't get merged with the black ones.
+//
+type octreeNode struct {
+	children [16]*octreeNode
+	leaf     bool
+	count    int
+	sums     [4]int
+}
+
+//
+// octree returns a palette of (at most) numColours colours built with the octree algorithm
+//
+func octree(colours []colourCount, numColours int) color.Palette {
+	root := &octreeNode{}
+	levels := [8][]*octreeNode{} // the nodes at each depth that still have children
+	levels[0] = append(levels[0], root)
+	leaves := 0
+
+	for _, cc := range colours {
+		node := root
+
+		for depth := 0; depth < 8; depth++ {
+			node.count += cc.count
+			shift := uint(7 - depth)
+			index := 0
+			for channel := 0; channel < 4; channel++ {
+				index |= int(channelValue(cc.colour, channel)>>shift&1) << uint(channel)
+			}
+
+			child := node.children[index]
+			if child == nil {
+				child = &octreeNode{leaf: depth == 7}
+				node.children[index] = child
+				if child.leaf {
+					leaves++
+				} else {
+					levels[depth+1] = append(levels[depth+1], child)
+				}
+			}
+
+			node = child
+		}
+
+		node.count += cc.count
+		for channel := 0; channel < 4; channel++ {
+			node.sums[channel] += int(channelValue(cc.colour, channel)) * cc.count
+		}
+	}
+
+	// merge the least used nodes at the deepest level first (their children are leaves by the time we get to them)
+	for _, level := range levels {
+		sort.SliceStable(level, func(i, j int) bool {
+			return level[i].count > level[j].count
+		})
+	}
+
+	for depth := 7; depth >= 0 && leaves > numColours; depth-- {
+		for len(levels[depth]) > 0 && leaves > numColours {
+			node := levels[depth][len(levels[depth])-1]
+			levels[depth] = levels[depth][:len(levels[depth])-1]
+
+			for i, child := range node.children {
+				if child == nil {
+					continue
+				}
+
+				for channel := 0; channel < 4; channel++ {
+					node.sums[channel] += child.sums[channel]
+				}
+
+				node.children[i] = nil
+				leaves--
+			}
+
+			node.leaf = true
+			leaves++
+		}
+	}
+
+	palette := color.Palette{}
+	root.collectLeaves(&palette)
+
+	return palette
+}
+
+//
+// collectLeaves appends the average colour of every leaf under the node to the palette
+//
+func (node *octreeNode) collectLeaves(palette *color.Palette) {
+	if node.leaf {
+		if node.count > 0 {
+			*palette = append(*palette, colourFromSums(node.sums, node.count))
+		}
+
+		return
+	}
+
+	for _, child := range node.children {
+		if child != nil {
+			child.collectLeaves(palette)
+		}
+	}
+}
+
+//
+// kMeans refines the palette with k-means clustering of the colours, and returns the new palette
+//
+func kMeans(colours []colourCount, palette color.Palette) color.Palette {
+	centres := paletteRGBA(palette)
+	assignments := make([]int, len(colours))
+	for i := range assignments {
+		assignments[i] = -1
+	}
+
+	for iteration := 0; iteration < kMeansIterations; iteration++ {
+		changed := false
+		sums := make([][4]int, len(centres))
+		counts := make([]int, len(centres))
+
+		for i, cc := range colours {
+			nearest := nearestColourIndex(centres, cc.colour)
+			if nearest != assignments[i] {
+				assignments[i] = nearest
+				changed = true
+			}
+
+			for channel := 0; channel < 4; channel++ {
+				sums[nearest][channel] += int(channelValue(cc.colour, channel)) * cc.count
+			}
+			counts[nearest] += cc.count
+		}
+
+		if !changed {
+			break
+		}
+
+		// (a centre that has no colours left just stays where it is)
+		for i := range centres {
+			if counts[i] > 0 {
+				centres[i] = colourFromSums(sums[i], counts[i])
+			}
+		}
+	}
+
+	newPalette := color.Palette{}
+	for _, c := range centres {
+		newPalette = append(newPalette, c)
+	}
+
+	return newPalette
+}
+
+//
+// nearestColourIndex returns the index of the colour in the palette closest to c (by the squared distance
+// between them, treating R, G, B and A as the 4 axes)
+//
+func nearestColourIndex(palette []color.RGBA, c color.RGBA) int {
+	best, bestDistance := 0, -1
+	for i, p := range palette {
+		distance := colourDistance(p, c)
+		if bestDistance == -1 || distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+
+	return best
+}
+
+//
+// colourDistance returns the squared distance between 2 colours
+//
+func colourDistance(a, b color.RGBA) int {
+	dr := int(a.R) - int(b.R)
+	dg := int(a.G) - int(b.G)
+	db := int(a.B) - int(b.B)
+	da := int(a.A) - int(b.A)
+	return dr*dr + dg*dg + db*db + da*da
+}
+
+//
+// paletteRGBA returns the colours of the palette as color.RGBA's
+//
+func paletteRGBA(palette color.Palette) []color.RGBA {
+	colours := make([]color.RGBA, len(palette))
+	for i, c := range palette {
+		colours[i] = color.RGBAModel.Convert(c).(color.RGBA)
+	}
+
+	return colours
+}
+
+//
+// averageColour returns the average of the colours, weighted by how often each is used
+//
+func averageColour(colours []colourCount) color.RGBA {
+	sums := [4]int{}
+	total := 0
+	for _, cc := range colours {
+		for channel := 0; channel < 4; channel++ {
+			sums[channel] += int(channelValue(cc.colour, channel)) * cc.count
+		}
+		total += cc.count
+	}
+
+	return colourFromSums(sums, total)
+}
+
+//
+// colourFromSums returns the colour with each channel set to it's sum divided by count (rounded)
+//
+func colourFromSums(sums [4]int, count int) color.RGBA {
+	return premultipliedRGBA(
+		float64(sums[0])/float64(count),
+		float64(sums[1])/float64(count),
+		float64(sums[2])/float64(count),
+		float64(sums[3])/float64(count),
+	)
+}
+
+//
+// channelValue returns the value of channel (0-3 for R, G, B, A) of the colour
+//
+func channelValue(c color.RGBA, channel int) uint8 {
+	switch channel {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	case 2:
+		return c.B
+	}
+
+	return c.A
+}
+
+//
+// packRGBA returns the colour packed into a single number (so colours can be sorted)
+//
+func packRGBA(c color.RGBA) uint32 {
+	return uint32(c.R)<<24 | uint32(c.G)<<16 | uint32(c.B)<<8 | uint32(c.A)
+}
@@ -0,0 +1,37 @@
+package monkey
+
+import "runtime"
+import "sync"
+
+//
+// parallelColumns calls fn once for every column (x = 0 to width-1) of an image, spreading the columns across
+// all the CPUs we have. As the ImageMatrix is stored as columns, each call can safely write it's own column of
+// a new matrix without any locking, as long as it only reads from everything else.
+//
+func parallelColumns(width int, fn func(x int)) {
+	workers := runtime.NumCPU()
+	if workers > width {
+		workers = width
+	}
+
+	columns := make(chan int, width)
+	for x := 0; x < width; x++ {
+		columns <- x
+	}
+	close(columns)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+
+			for x := range columns {
+				fn(x)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,203 @@
+package monkey
+
+import "image/color"
+import "log"
+import "math"
+
+//
+// FloatPoint is a (sub-pixel) position in an image, where (0, 0) is the top left corner of the image and the
+// centre of pixel (x, y) is at (x+0.5, y+0.5)
+//
+type FloatPoint struct {
+	X float64
+	Y float64
+}
+
+//
+// Homography is a 3x3 projective transformation matrix; it maps the point (x, y) to
+//     ((h00*x + h01*y + h02) / w, (h10*x + h11*y + h12) / w)   where   w = h20*x + h21*y + h22
+// Unlike an AffineMatrix, straight lines stay straight, but parallel lines don't have to stay parallel (which
+// is exactly what happens when you photograph a flat document at an angle)
+//
+type Homography [3][3]float64
+
+//
+// AffineHomography returns the homography that does the same as the given affine matrix
+//
+func AffineHomography(m AffineMatrix) Homography {
+	return Homography{
+		{m[0][0], m[0][1], m[0][2]},
+		{m[1][0], m[1][1], m[1][2]},
+		{0, 0, 1},
+	}
+}
+
+//
+// Apply returns where the point (x, y) ends up after it has been transformed by h. It returns false if the
+// point ends up "at infinity" (or behind the camera), in which case it has no sensible position.
+//
+func (h Homography) Apply(x, y float64) (float64, float64, bool) {
+	w := h[2][0]*x + h[2][1]*y + h[2][2]
+	if w <= 1e-12 {
+		return 0, 0, false
+	}
+
+	return (h[0][0]*x + h[0][1]*y + h[0][2]) / w, (h[1][0]*x + h[1][1]*y + h[1][2]) / w, true
+}
+
+//
+// Multiply returns the homography that applies n first, and then h (ie. h x n)
+//
+func (h Homography) Multiply(n Homography) Homography {
+	var result Homography
+
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				result[i][j] += h[i][k] * n[k][j]
+			}
+		}
+	}
+
+	return result
+}
+
+//
+// Inverse returns the homography that undoes h. It will log a fatal error if h can't be inverted.
+//
+func (h Homography) Inverse() Homography {
+	// the inverse is the adjugate divided by the determinant...
+	var adj Homography
+	adj[0][0] = h[1][1]*h[2][2] - h[1][2]*h[2][1]
+	adj[0][1] = h[0][2]*h[2][1] - h[0][1]*h[2][2]
+	adj[0][2] = h[0][1]*h[1][2] - h[0][2]*h[1][1]
+	adj[1][0] = h[1][2]*h[2][0] - h[1][0]*h[2][2]
+	adj[1][1] = h[0][0]*h[2][2] - h[0][2]*h[2][0]
+	adj[1][2] = h[0][2]*h[1][0] - h[0][0]*h[1][2]
+	adj[2][0] = h[1][0]*h[2][1] - h[1][1]*h[2][0]
+	adj[2][1] = h[0][1]*h[2][0] - h[0][0]*h[2][1]
+	adj[2][2] = h[0][0]*h[1][1] - h[0][1]*h[1][0]
+
+	det := h[0][0]*adj[0][0] + h[0][1]*adj[1][0] + h[0][2]*adj[2][0]
+	if math.Abs(det) < 1e-12 {
+		log.Fatalln("The homography can not be inverted:", h)
+	}
+
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			adj[i][j] /= det
+		}
+	}
+
+	return adj
+}
+
+//
+// HomographyFromQuad returns the homography that maps each of the 4 src points onto the matching dst point.
+// No 3 of the points (in either quad) may lie on a straight line.
+//
+// See https://en.wikipedia.org/wiki/Homography_(computer_vision) - we fix h22 to 1, which leaves 8 unknowns,
+// and each pair of points gives us 2 equations, so we have 8 linear equations to solve.
+//
+func HomographyFromQuad(src, dst [4]FloatPoint) Homography {
+	var a [8][9]float64 // the augmented matrix of the linear system
+
+	for i := 0; i < 4; i++ {
+		x, y := src[i].X, src[i].Y
+		u, v := dst[i].X, dst[i].Y
+
+		a[2*i] = [9]float64{x, y, 1, 0, 0, 0, -u * x, -u * y, u}
+		a[2*i+1] = [9]float64{0, 0, 0, x, y, 1, -v * x, -v * y, v}
+	}
+
+	// Gaussian elimination with partial pivoting...
+	for col := 0; col < 8; col++ {
+		pivot := col
+		for row := col + 1; row < 8; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			log.Fatalln("Can not compute a homography from the points (are 3 of them in a line?):", src, dst)
+		}
+
+		a[col], a[pivot] = a[pivot], a[col]
+
+		for row := 0; row < 8; row++ {
+			if row == col {
+				continue
+			}
+
+			factor := a[row][col] / a[col][col]
+			for k := col; k < 9; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+		}
+	}
+
+	var h [8]float64
+	for i := 0; i < 8; i++ {
+		h[i] = a[i][8] / a[i][i]
+	}
+
+	return Homography{
+		{h[0], h[1], h[2]},
+		{h[3], h[4], h[5]},
+		{h[6], h[7], 1},
+	}
+}
+
+//
+// HomographyToRectangle returns the homography that maps the 4 corners (in the order top left, top right,
+// bottom right, bottom left) onto the corners of a width x height rectangle at the origin
+//
+func HomographyToRectangle(corners [4]FloatPoint, width, height int) Homography {
+	w := float64(width)
+	h := float64(height)
+
+	return HomographyFromQuad(corners, [4]FloatPoint{{0, 0}, {w, 0}, {w, h}, {0, h}})
+}
+
+//
+// PerspectiveTransform returns a new width x height ImageMatrix with the homography applied to the image (the
+// homography maps positions in this image to positions in the new one). The filter decides how we interpolate
+// between the source pixels (the same choices as Resize), and any part of the new canvas that isn't covered
+// by the image is set to the background colour.
+//
+func (im ImageMatrix) PerspectiveTransform(h Homography, width, height int, filter ResampleFilter, background color.RGBA) ImageMatrix {
+	inverse := h.Inverse()
+
+	mapping := func(x, y int) (float64, float64, bool) {
+		return inverse.Apply(float64(x)+0.5, float64(y)+0.5)
+	}
+
+	return im.warp(width, height, mapping, filter, background)
+}
+
+//
+// Deskew takes the 4 corners of something photographed at an angle (eg. a document or whiteboard) in the order
+// top left, top right, bottom right, bottom left, and returns a new width x height ImageMatrix with that area
+// "flattened" out into a rectangle.
+// If width or height are 0, they are estimated from the average lengths of the opposite sides of the quad.
+//
+func (im ImageMatrix) Deskew(corners [4]FloatPoint, width, height int, filter ResampleFilter) ImageMatrix {
+	if width == 0 {
+		width = maxInt(1, int(math.Floor((distance(corners[0], corners[1])+distance(corners[3], corners[2]))/2+0.5)))
+	}
+
+	if height == 0 {
+		height = maxInt(1, int(math.Floor((distance(corners[0], corners[3])+distance(corners[1], corners[2]))/2+0.5)))
+	}
+
+	h := HomographyToRectangle(corners, width, height)
+	return im.PerspectiveTransform(h, width, height, filter, color.RGBA{})
+}
+
+//
+// distance returns the distance between two points
+//
+func distance(a, b FloatPoint) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
@@ -129,3 +129,73 @@ func debugPrintMatrix(matrix ImageMatrix) {
 		}
 	}
 }
+
+//
+// clampInt returns v limited to the range min..max
+//
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	} else if v > max {
+		return max
+	}
+
+	return v
+}
+
+//
+// clampFloat returns v limited to the range min..max
+//
+func clampFloat(v, min, max float64) float64 {
+	if v < min {
+		return min
+	} else if v > max {
+		return max
+	}
+
+	return v
+}
+
+//
+// maxInt returns the larger of a and b
+//
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+//
+// minInt returns the smaller of a and b
+//
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+//
+// minUint8 returns the smaller of a and b
+//
+func minUint8(a, b uint8) uint8 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+//
+// maxUint8 returns the larger of a and b
+//
+func maxUint8(a, b uint8) uint8 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
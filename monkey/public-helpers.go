@@ -1,6 +1,8 @@
 package monkey
 
 import "image"
+import "image/color"
+import "io"
 import "io/ioutil"
 import "../util"
 
@@ -37,13 +39,56 @@ func ImageMatrixToImage(imageMatrix ImageMatrix) image.Image {
 }
 
 //
-// LoadImageFromFile takes a filename and returns the contents of that file as a string...
+// Encode writes the ImageMatrix to w in the given format (eg. util.FormatPNG), using the options (which can be
+// nil); see util.Encode...
+//
+func (im ImageMatrix) Encode(w io.Writer, format util.Format, options *util.SaveOptions) error {
+	return util.Encode(w, ImageMatrixToImage(im), format, options)
+}
+
+//
+// ImageToImageMatrix converts an image.Image (eg. one that has been decoded from a file) into an ImageMatrix
+//
+func ImageToImageMatrix(src image.Image) ImageMatrix {
+	return imageRectToImageMatrix(src, src.Bounds())
+}
+
+//
+// imageRectToImageMatrix converts the part of src inside rect into an ImageMatrix (the top left of rect becomes
+// 0, 0 in the matrix)
+//
+func imageRectToImageMatrix(src image.Image, rect image.Rectangle) ImageMatrix {
+	imageMatrix := ImageMatrix{}
+
+	for x := rect.Min.X; x < rect.Max.X; x++ {
+		column := make([]color.RGBA, rect.Dy())
+
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			colour := src.At(x, y)
+			// column[y] = colour
+
+			// Doing the below as JPG's usually have a color.YCbCr model, and we want
+			// to keep things in RGBA for simplicity of code... for now :)
+			r, g, b, a := colour.RGBA()
+
+			// right shift the values by 8 bits as colour.RGBA() will return a uint32, and we want to keep the most
+			// significant 8 bits NOT the least significant 8 bits
+			column[y-rect.Min.Y] = color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+		}
+
+		imageMatrix = append(imageMatrix, column)
+	}
+
+	return imageMatrix
+}
+
+//
+// LoadImageFromFile takes a filename and returns a Monkey for the contents of that file...
 //
 func LoadImageFromFile(filename string) *Monkey {
 	sliceOfBytes, err := ioutil.ReadFile(filename)
 	util.CheckError(err)
-	data := string(sliceOfBytes)
 	monkey := &Monkey{}
-	monkey.SetRawData(data)
+	monkey.SetRawBytes(sliceOfBytes)
 	return monkey
 }
@@ -0,0 +1,328 @@
+package monkey
+
+import "image"
+import "image/color"
+import "log"
+import "math"
+
+//
+// ResampleFilter is the filter (kernel) used when we resample an ImageMatrix, eg. when resizing it...
+//
+type ResampleFilter int
+
+//
+// The resample filters we support. They are ordered (roughly) from the fastest / lowest quality, to the
+// slowest / highest quality...
+//
+const (
+	// NearestNeighbour picks the closest source pixel when enlarging. When shrinking it averages the
+	// source pixels covered by each destination pixel (area-averaging) so we don't just drop pixels.
+	NearestNeighbour ResampleFilter = iota
+
+	// Box averages the pixels covered by the destination pixel (same as NearestNeighbour on downscale)
+	Box
+
+	// Bilinear is a linear interpolation between the 2 nearest pixels in each direction (tent filter)
+	Bilinear
+
+	// CatmullRom is the bicubic filter with B=0, C=0.5; it's sharp, with a small amount of ringing
+	CatmullRom
+
+	// Mitchell is the bicubic filter with B=1/3, C=1/3; it's a little softer than CatmullRom
+	Mitchell
+
+	// Lanczos3 is a windowed sinc filter with a 3 pixel radius; it's the sharpest (and slowest)
+	Lanczos3
+)
+
+//
+// String returns the name of the filter (handy for debug output and filenames)...
+//
+func (f ResampleFilter) String() string {
+	switch f {
+	case NearestNeighbour:
+		return "NearestNeighbour"
+	case Box:
+		return "Box"
+	case Bilinear:
+		return "Bilinear"
+	case CatmullRom:
+		return "CatmullRom"
+	case Mitchell:
+		return "Mitchell"
+	case Lanczos3:
+		return "Lanczos3"
+	}
+
+	return "Unknown"
+}
+
+//
+// support returns how far (in source pixels, at a scale of 1) the filter looks either side of the
+// sample point
+//
+func (f ResampleFilter) support() float64 {
+	switch f {
+	case NearestNeighbour, Box:
+		return 0.5
+	case Bilinear:
+		return 1
+	case CatmullRom, Mitchell:
+		return 2
+	case Lanczos3:
+		return 3
+	}
+
+	log.Fatalln("Unknown resample filter:", int(f))
+	return 0
+}
+
+//
+// weight returns the weight of the filter at distance x from the sample point
+//
+func (f ResampleFilter) weight(x float64) float64 {
+	x = math.Abs(x)
+
+	switch f {
+	case NearestNeighbour, Box:
+		if x <= 0.5 {
+			return 1
+		}
+		return 0
+
+	case Bilinear:
+		if x < 1 {
+			return 1 - x
+		}
+		return 0
+
+	case CatmullRom:
+		return bicubic(x, 0, 0.5)
+
+	case Mitchell:
+		return bicubic(x, 1.0/3.0, 1.0/3.0)
+
+	case Lanczos3:
+		if x == 0 {
+			return 1
+		} else if x < 3 {
+			return sinc(x) * sinc(x/3)
+		}
+		return 0
+	}
+
+	return 0
+}
+
+//
+// bicubic is the Mitchell-Netravali family of cubic filters; B and C pick which member of the family we get
+// See https://en.wikipedia.org/wiki/Mitchell%E2%80%93Netravali_filters
+//
+func bicubic(x, b, c float64) float64 {
+	if x < 1 {
+		return ((12-9*b-6*c)*x*x*x + (-18+12*b+6*c)*x*x + (6 - 2*b)) / 6
+	} else if x < 2 {
+		return ((-b-6*c)*x*x*x + (6*b+30*c)*x*x + (-12*b-48*c)*x + (8*b + 24*c)) / 6
+	}
+
+	return 0
+}
+
+//
+// sinc is the normalised sinc function, sin(pi * x) / (pi * x)
+//
+func sinc(x float64) float64 {
+	x = x * math.Pi
+	return math.Sin(x) / x
+}
+
+//
+// resampleTap is the weight a particular source pixel has on a destination pixel
+//
+type resampleTap struct {
+	index  int
+	weight float64
+}
+
+//
+// resampleWeights works out, for each destination pixel along one axis, which source pixels contribute to it
+// and how much...
+//
+// When we shrink an image the filter is stretched by the scale factor, so every destination pixel takes in
+// all the source pixels it covers (this is the prefiltering that stops downscaled images from aliasing).
+// Taps that fall outside the image are clamped to the edge pixels.
+//
+func resampleWeights(srcSize, dstSize int, filter ResampleFilter) [][]resampleTap {
+	scale := float64(srcSize) / float64(dstSize)
+	filterScale := math.Max(scale, 1)
+	radius := filter.support() * filterScale
+	weights := make([][]resampleTap, dstSize)
+
+	for i := 0; i < dstSize; i++ {
+		// the centre of the destination pixel, mapped back into the source image's coordinates
+		centre := (float64(i)+0.5)*scale - 0.5
+
+		// Nearest neighbour, when enlarging, just needs the one closest pixel...
+		if filter == NearestNeighbour && scale <= 1 {
+			index := clampInt(int(math.Floor(centre+0.5)), 0, srcSize-1)
+			weights[i] = []resampleTap{{index, 1}}
+			continue
+		}
+
+		start := int(math.Ceil(centre - radius))
+		end := int(math.Floor(centre + radius))
+		taps := []resampleTap{}
+		total := 0.0
+
+		for j := start; j <= end; j++ {
+			w := filter.weight((float64(j) - centre) / filterScale)
+			if w == 0 {
+				continue
+			}
+
+			taps = append(taps, resampleTap{clampInt(j, 0, srcSize-1), w})
+			total += w
+		}
+
+		// Normalise the weights so that they add up to 1 (and so we don't brighten/darken the image)
+		if total != 0 {
+			for t := range taps {
+				taps[t].weight /= total
+			}
+		}
+
+		weights[i] = taps
+	}
+
+	return weights
+}
+
+//
+// Resize returns a new ImageMatrix scaled to width x height using the given filter.
+// If width or height is 0, it is worked out from the other so that the aspect ratio is preserved.
+//
+// The resize is done in two passes (first along x, then along y) as all the filters we support are separable.
+//
+func (im ImageMatrix) Resize(width, height int, filter ResampleFilter) ImageMatrix {
+	srcWidth := im.GetWidth()
+	srcHeight := im.GetHeight()
+
+	if width < 0 || height < 0 || (width == 0 && height == 0) {
+		log.Fatalln("Invalid size to resize the image to:", width, height)
+	}
+
+	if width == 0 {
+		width = maxInt(1, int(math.Floor(float64(srcWidth)*float64(height)/float64(srcHeight)+0.5)))
+	} else if height == 0 {
+		height = maxInt(1, int(math.Floor(float64(srcHeight)*float64(width)/float64(srcWidth)+0.5)))
+	}
+
+	// resample along x (into a width x srcHeight matrix)...
+	xWeights := resampleWeights(srcWidth, width, filter)
+	horizontal := make([][][4]float64, width)
+
+	for x := 0; x < width; x++ {
+		column := make([][4]float64, srcHeight)
+
+		for y := 0; y < srcHeight; y++ {
+			var total [4]float64
+
+			for _, tap := range xWeights[x] {
+				c := im[tap.index][y]
+				total[0] += float64(c.R) * tap.weight
+				total[1] += float64(c.G) * tap.weight
+				total[2] += float64(c.B) * tap.weight
+				total[3] += float64(c.A) * tap.weight
+			}
+
+			column[y] = total
+		}
+
+		horizontal[x] = column
+	}
+
+	// ...and then resample along y (into the final width x height matrix)
+	yWeights := resampleWeights(srcHeight, height, filter)
+	newMatrix := ImageMatrix{}
+
+	for x := 0; x < width; x++ {
+		column := make([]color.RGBA, height)
+
+		for y := 0; y < height; y++ {
+			var total [4]float64
+
+			for _, tap := range yWeights[y] {
+				c := horizontal[x][tap.index]
+				total[0] += c[0] * tap.weight
+				total[1] += c[1] * tap.weight
+				total[2] += c[2] * tap.weight
+				total[3] += c[3] * tap.weight
+			}
+
+			column[y] = premultipliedRGBA(total[0], total[1], total[2], total[3])
+		}
+
+		newMatrix = append(newMatrix, column)
+	}
+
+	return newMatrix
+}
+
+//
+// ResizeToFit scales the image (preserving the aspect ratio) so that it fits inside width x height.
+// One of the sides of the new image will be exactly width or height, the other may be smaller.
+//
+func (im ImageMatrix) ResizeToFit(width, height int, filter ResampleFilter) ImageMatrix {
+	if width <= 0 || height <= 0 {
+		log.Fatalln("Invalid size to resize the image to:", width, height)
+	}
+
+	srcWidth := float64(im.GetWidth())
+	srcHeight := float64(im.GetHeight())
+	scale := math.Min(float64(width)/srcWidth, float64(height)/srcHeight)
+
+	newWidth := clampInt(int(math.Floor(srcWidth*scale+0.5)), 1, width)
+	newHeight := clampInt(int(math.Floor(srcHeight*scale+0.5)), 1, height)
+
+	return im.Resize(newWidth, newHeight, filter)
+}
+
+//
+// ResizeToFill scales the image (preserving the aspect ratio) so that it covers all of width x height, and
+// then crops off whatever overhangs (equally from both sides) so that the result is exactly width x height.
+//
+func (im ImageMatrix) ResizeToFill(width, height int, filter ResampleFilter) ImageMatrix {
+	if width <= 0 || height <= 0 {
+		log.Fatalln("Invalid size to resize the image to:", width, height)
+	}
+
+	srcWidth := float64(im.GetWidth())
+	srcHeight := float64(im.GetHeight())
+	scale := math.Max(float64(width)/srcWidth, float64(height)/srcHeight)
+
+	newWidth := maxInt(width, int(math.Floor(srcWidth*scale+0.5)))
+	newHeight := maxInt(height, int(math.Floor(srcHeight*scale+0.5)))
+	resized := im.Resize(newWidth, newHeight, filter)
+
+	// crop the centre of the resized image (it's a new ImageMatrix no one else has, so the crop can share it)...
+	offsetX := (newWidth - width) / 2
+	offsetY := (newHeight - height) / 2
+
+	return resized.CropInPlace(image.Rect(offsetX, offsetY, offsetX+width, offsetY+height))
+}
+
+//
+// premultipliedRGBA rounds and clamps the (premultiplied alpha) channel values into a color.RGBA.
+// Filters with negative lobes (eg. CatmullRom, Lanczos3) can overshoot, so the colour channels are also
+// clamped to the alpha (a premultiplied colour can never be greater than it's alpha).
+//
+func premultipliedRGBA(r, g, b, a float64) color.RGBA {
+	alpha := clampFloat(a, 0, 255)
+
+	return color.RGBA{
+		uint8(clampFloat(r, 0, alpha) + 0.5),
+		uint8(clampFloat(g, 0, alpha) + 0.5),
+		uint8(clampFloat(b, 0, alpha) + 0.5),
+		uint8(alpha + 0.5),
+	}
+}
@@ -0,0 +1,178 @@
+package monkey
+
+import "image/color"
+import "log"
+import "math"
+
+//
+// SeamColour is the colour seams are painted in when we show them (eg. in the frames of a seam carving animation)
+//
+var SeamColour = color.RGBA{255, 0, 255, 255}
+
+//
+// SeamEnergy returns the energy map used to find seams; the Sobel gradient magnitude of the luminance, plus that
+// of the alpha (so that the edge of a transparent area counts as an edge too)
+//
+func (im ImageMatrix) SeamEnergy() FloatMatrix {
+	_, _, _, alpha := im.splitChannels()
+	return im.Gradient(Sobel).Magnitude.add(alpha.Gradient(Sobel).Magnitude)
+}
+
+//
+// FindSeamHorizontal returns the horizontal seam (one pixel in every column, each within 1 pixel up or down of the
+// last, from the left of the image to the right) with the lowest total energy. It's the best seam over the whole
+// image, not just a greedy one; it works out column by column the lowest energy of any seam ending at each pixel,
+// and then follows it back from the end (dynamic programming).
+//
+func (im ImageMatrix) FindSeamHorizontal() Path {
+	energy := im.SeamEnergy()
+	width := energy.GetWidth()
+	height := energy.GetHeight()
+
+	// total[x][y] is the energy of the best seam from the left edge to x, y; from[x][y] is the y it came from
+	total := NewFloatMatrix(width, height)
+	from := make([][]int, width)
+	copy(total[0], energy[0])
+
+	for x := 1; x < width; x++ {
+		from[x] = make([]int, height)
+
+		for y := 0; y < height; y++ {
+			best := y
+			for j := maxInt(0, y-1); j <= minInt(height-1, y+1); j++ {
+				if total[x-1][j] < total[x-1][best] {
+					best = j
+				}
+			}
+
+			total[x][y] = energy[x][y] + total[x-1][best]
+			from[x][y] = best
+		}
+	}
+
+	end, lowest := 0, math.Inf(1)
+	for y, t := range total[width-1] {
+		if t < lowest {
+			end, lowest = y, t
+		}
+	}
+
+	seam := make(Path, width)
+	y := end
+	for x := width - 1; x >= 0; x-- {
+		seam[x] = Point{x, y}
+		if x > 0 {
+			y = from[x][y]
+		}
+	}
+
+	return seam
+}
+
+//
+// RemoveSeamHorizontal returns a new ImageMatrix, 1 pixel shorter, with the pixels of the seam taken out (the
+// pixels below the seam in each column move up by one)
+//
+func (im ImageMatrix) RemoveSeamHorizontal(seam Path) ImageMatrix {
+	if len(seam) != im.GetWidth() {
+		log.Fatalln("The seam must have a point in every column:", len(seam), im.GetWidth())
+	}
+
+	newMatrix := ImageMatrix{}
+	for x, column := range im {
+		y := seam[x].y
+		newColumn := make(ImageRow, 0, len(column)-1)
+		newColumn = append(newColumn, column[:y]...)
+		newColumn = append(newColumn, column[y+1:]...)
+		newMatrix = append(newMatrix, newColumn)
+	}
+
+	return newMatrix
+}
+
+//
+// PaintSeam returns a copy of the ImageMatrix with the pixels of the seam set to the colour
+//
+func (im ImageMatrix) PaintSeam(seam Path, colour color.RGBA) ImageMatrix {
+	newMatrix := im.Clone()
+	for _, point := range seam {
+		newMatrix[point.x][point.y] = colour
+	}
+
+	return newMatrix
+}
+
+//
+// SeamCarveHorizontalBy returns a new ImageMatrix with the given number of horizontal seams carved out of it (so
+// it's that many pixels shorter), taking out the lowest energy seam each time.
+//
+// If animation isn't nil, a frame is added to it for every seam, showing the image with the seam about to be
+// removed painted in SeamColour, and one more at the end showing the result; the frames are all kept at the
+// original size (the space left at the bottom is transparent), so they can be saved as an animated gif or a
+// numbered sequence of images.
+//
+func (im ImageMatrix) SeamCarveHorizontalBy(seams int, animation *Animation) ImageMatrix {
+	width := im.GetWidth()
+	height := im.GetHeight()
+	if seams < 0 || seams >= height {
+		log.Fatalln("Can't carve that many seams out of the image:", seams, height)
+	}
+
+	addFrame := func(matrix ImageMatrix, delay int) {
+		if animation != nil {
+			frame := matrix.CanvasResize(width, height, AnchorTopLeft, color.RGBA{})
+			animation.Frames = append(animation.Frames, Frame{Matrix: frame, Delay: delay})
+		}
+	}
+
+	// (a clone, so that carving no seams still returns a new ImageMatrix rather than the one we were given)
+	newMatrix := im.Clone()
+	for i := 0; i < seams; i++ {
+		seam := newMatrix.FindSeamHorizontal()
+		addFrame(newMatrix.PaintSeam(seam, SeamColour), 10)
+		newMatrix = newMatrix.RemoveSeamHorizontal(seam)
+	}
+
+	// (hold the result on screen for a couple of seconds before the animation loops)
+	addFrame(newMatrix, 200)
+
+	return newMatrix
+}
+
+//
+// SeamCarveHorizontal returns a new ImageMatrix with the lowest energy horizontal seam carved out of it (so it's
+// one pixel shorter); it's SeamCarveHorizontalBy(1, nil)
+//
+func (im ImageMatrix) SeamCarveHorizontal() ImageMatrix {
+	return im.SeamCarveHorizontalBy(1, nil)
+}
+
+//
+// SeamCarveVertical returns a new ImageMatrix with the lowest energy vertical seam carved out of it (so it's one
+// pixel narrower); it's SeamCarveVerticalBy(1, nil)
+//
+func (im ImageMatrix) SeamCarveVertical() ImageMatrix {
+	return im.SeamCarveVerticalBy(1, nil)
+}
+
+//
+// SeamCarveVerticalBy is SeamCarveHorizontalBy for vertical seams (top to bottom), so the image gets narrower;
+// the image is transposed, carved, and transposed back (and so are the frames)
+//
+func (im ImageMatrix) SeamCarveVerticalBy(seams int, animation *Animation) ImageMatrix {
+	var transposed *Animation
+	if animation != nil {
+		transposed = &Animation{}
+	}
+
+	newMatrix := im.Transpose().SeamCarveHorizontalBy(seams, transposed).Transpose()
+
+	if animation != nil {
+		for _, frame := range transposed.Frames {
+			frame.Matrix = frame.Matrix.Transpose()
+			animation.Frames = append(animation.Frames, frame)
+		}
+	}
+
+	return newMatrix
+}
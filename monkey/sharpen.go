@@ -0,0 +1,106 @@
+package monkey
+
+import "image"
+import "log"
+import "math"
+
+//
+// UnsharpMask returns a new ImageMatrix sharpened with an unsharp mask; the image is blurred (a gaussian with a
+// sigma of radius), and the difference between the image and the blurred copy (the fine detail) is multiplied
+// by amount and added back on. eg. an amount of 0.5 boosts the detail by 50%.
+//
+// Differences smaller than threshold (0-255) are left alone, so that smooth areas (sky, skin) and their noise
+// don't get sharpened. If luminanceOnly is true, only the brightness is sharpened; the red, green and blue
+// channels are all moved by the same amount, which avoids the coloured fringes you get along edges when each
+// channel is sharpened on it's own.
+//
+func (im ImageMatrix) UnsharpMask(radius, amount float64, threshold uint8, luminanceOnly bool) ImageMatrix {
+	if radius <= 0 || amount < 0 {
+		log.Fatalln("Invalid unsharp mask parameters:", radius, amount)
+	}
+
+	blurred := im.GaussianBlur(radius)
+	newMatrix := ImageMatrix{}
+
+	for x, column := range im {
+		newColumn := make(ImageRow, len(column))
+
+		for y, c := range column {
+			b := blurred[x][y]
+			channels := [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+			blurredChannels := [3]float64{float64(b.R), float64(b.G), float64(b.B)}
+
+			if luminanceOnly {
+				detail := luminance(c) - luminance(b)
+				if math.Abs(detail) >= float64(threshold) {
+					for i := range channels {
+						channels[i] += detail * amount
+					}
+				}
+			} else {
+				for i := range channels {
+					detail := channels[i] - blurredChannels[i]
+					if math.Abs(detail) >= float64(threshold) {
+						channels[i] += detail * amount
+					}
+				}
+			}
+
+			newColumn[y] = premultipliedRGBA(channels[0], channels[1], channels[2], float64(c.A))
+		}
+
+		newMatrix = append(newMatrix, newColumn)
+	}
+
+	return newMatrix
+}
+
+//
+// HighPass returns the fine detail of the image (the image minus a gaussian blur of it, with a sigma of radius)
+// on a mid grey background; where the image is flat the result is grey, and the edges show up lighter or darker.
+// If luminanceOnly is true, the detail is worked out from the brightness only, so the result is greyscale.
+//
+func (im ImageMatrix) HighPass(radius float64, luminanceOnly bool) ImageMatrix {
+	blurred := im.GaussianBlur(radius)
+	newMatrix := ImageMatrix{}
+
+	for x, column := range im {
+		newColumn := make(ImageRow, len(column))
+
+		for y, c := range column {
+			b := blurred[x][y]
+			detail := [3]float64{
+				float64(c.R) - float64(b.R),
+				float64(c.G) - float64(b.G),
+				float64(c.B) - float64(b.B),
+			}
+
+			if luminanceOnly {
+				grey := luminance(c) - luminance(b)
+				detail = [3]float64{grey, grey, grey}
+			}
+
+			// (on top of mid grey, and premultiplied by the pixel's alpha so transparent areas stay transparent)
+			alpha := float64(c.A)
+			newColumn[y] = premultipliedRGBA(
+				clampFloat(detail[0]+128, 0, 255)*alpha/255,
+				clampFloat(detail[1]+128, 0, 255)*alpha/255,
+				clampFloat(detail[2]+128, 0, 255)*alpha/255,
+				alpha,
+			)
+		}
+
+		newMatrix = append(newMatrix, newColumn)
+	}
+
+	return newMatrix
+}
+
+//
+// HighPassSharpen returns a new ImageMatrix sharpened the way you would in GIMP/Photoshop by hand; the high pass
+// of the image is put on a layer above it with the overlay blend mode, at the given opacity (0 to 1). Mid grey
+// has no effect in overlay mode, so only the edges are changed.
+//
+func (im ImageMatrix) HighPassSharpen(radius, opacity float64, luminanceOnly bool) ImageMatrix {
+	return Composite(im, im.HighPass(radius, luminanceOnly), BlendOverlay, image.Point{}, opacity)
+}
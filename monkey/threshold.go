@@ -0,0 +1,187 @@
+package monkey
+
+import "log"
+import "math"
+
+//
+// AdaptiveMethod is how the local threshold is worked out for each pixel in AdaptiveThreshold...
+//
+type AdaptiveMethod int
+
+const (
+	// AdaptiveMean uses the plain average of the window around the pixel
+	AdaptiveMean AdaptiveMethod = iota
+
+	// AdaptiveGaussian uses a gaussian weighted average of the window (the nearer pixels count for more)
+	AdaptiveGaussian
+)
+
+//
+// LuminanceHistogram returns how many pixels there are of each brightness (0-255)
+//
+func (im ImageMatrix) LuminanceHistogram() [256]int {
+	var histogram [256]int
+
+	for _, column := range im {
+		for _, c := range column {
+			histogram[uint8(luminance(c)+0.5)]++
+		}
+	}
+
+	return histogram
+}
+
+//
+// Threshold returns a mask with the pixels brighter than level turned on (and the rest off). Use the mask's
+// ImageMatrix method to get a black and white image.
+//
+func (im ImageMatrix) Threshold(level uint8) BinaryMask {
+	mask := NewBinaryMask(im.GetWidth(), im.GetHeight())
+
+	for x, column := range im {
+		for y, c := range column {
+			mask[x][y] = uint8(luminance(c)+0.5) > level
+		}
+	}
+
+	return mask
+}
+
+//
+// OtsuThreshold works out the best level to threshold the image at with Otsu's method; it picks the level
+// that splits the luminance histogram into two classes (dark and bright) with the largest variance between
+// them. It works best on images with two clear "humps" in their histogram, eg. text on a page.
+// See https://en.wikipedia.org/wiki/Otsu%27s_method
+//
+func (im ImageMatrix) OtsuThreshold() uint8 {
+	histogram := im.LuminanceHistogram()
+
+	total := 0
+	sum := 0.0
+	for i, count := range histogram {
+		total += count
+		sum += float64(i * count)
+	}
+
+	bestLevel := 0
+	bestVariance := -1.0
+	darkCount := 0
+	darkSum := 0.0
+
+	for level := 0; level < 256; level++ {
+		darkCount += histogram[level]
+		darkSum += float64(level * histogram[level])
+		brightCount := total - darkCount
+
+		if darkCount == 0 || brightCount == 0 {
+			continue
+		}
+
+		darkMean := darkSum / float64(darkCount)
+		brightMean := (sum - darkSum) / float64(brightCount)
+		variance := float64(darkCount) * float64(brightCount) * (darkMean - brightMean) * (darkMean - brightMean)
+
+		if variance > bestVariance {
+			bestVariance = variance
+			bestLevel = level
+		}
+	}
+
+	return uint8(bestLevel)
+}
+
+//
+// TriangleThreshold works out the level to threshold the image at with the triangle method; it draws a line
+// from the peak of the luminance histogram to the far end of it, and picks the level where the histogram is
+// furthest below that line. It works best when there is one big peak (eg. the background) and a long tail.
+//
+func (im ImageMatrix) TriangleThreshold() uint8 {
+	histogram := im.LuminanceHistogram()
+
+	// find the first and last levels that are used, and the peak
+	first, last, peak := -1, -1, 0
+	for level, count := range histogram {
+		if count > 0 {
+			if first < 0 {
+				first = level
+			}
+			last = level
+		}
+
+		if count > histogram[peak] {
+			peak = level
+		}
+	}
+
+	// the line goes from the peak to whichever end of the histogram is furthest away from it
+	end := last
+	if peak-first > last-peak {
+		end = first
+	}
+
+	if end == peak {
+		return uint8(peak)
+	}
+
+	bestLevel := peak
+	bestDistance := -1.0
+	step := 1
+	if end < peak {
+		step = -1
+	}
+
+	// (the distance from the line, without dividing by the length of the line as it's the same for every level)
+	dx := float64(end - peak)
+	dy := float64(histogram[end] - histogram[peak])
+
+	for level := peak; level != end; level += step {
+		distance := math.Abs(dy*float64(level-peak) - dx*float64(histogram[level]-histogram[peak]))
+
+		if distance > bestDistance {
+			bestDistance = distance
+			bestLevel = level
+		}
+	}
+
+	// when the tail is on the dark side, the dark pixels are the interesting ones so we keep the level below them
+	if step < 0 {
+		bestLevel--
+	}
+
+	return uint8(clampInt(bestLevel, 0, 255))
+}
+
+//
+// AdaptiveThreshold returns a mask where each pixel is turned on if it is brighter than the (mean or gaussian
+// weighted) average of the (2*radius + 1) square window around it, minus c. As the threshold follows the local
+// brightness, it copes with uneven lighting (eg. a photo of a page with a shadow across it) where a single
+// global threshold can't.
+//
+func (im ImageMatrix) AdaptiveThreshold(method AdaptiveMethod, radius int, c float64) BinaryMask {
+	if radius < 1 {
+		log.Fatalln("The adaptive threshold radius must be at least 1:", radius)
+	}
+
+	brightness := im.Luminance()
+	var local FloatMatrix
+
+	switch method {
+	case AdaptiveMean:
+		local = brightness.BoxBlur(radius)
+	case AdaptiveGaussian:
+		// the same sigma OpenCV picks for a window of this size
+		size := float64(2*radius + 1)
+		local = brightness.GaussianBlur(0.3*((size-1)*0.5-1) + 0.8)
+	default:
+		log.Fatalln("Unknown adaptive threshold method:", int(method))
+	}
+
+	mask := NewBinaryMask(im.GetWidth(), im.GetHeight())
+	for x, column := range brightness {
+		for y, b := range column {
+			mask[x][y] = b > local[x][y]-c
+		}
+	}
+
+	return mask
+}
@@ -0,0 +1,374 @@
+package monkey
+
+import "image/color"
+import "log"
+import "math"
+
+//
+// AffineMatrix is a 2x3 affine transformation matrix...
+//     [a b c]
+//     [d e f]
+// which maps the point (x, y) to (a*x + b*y + c, d*x + e*y + f)
+//
+// Coordinates are continuous, with (0, 0) at the top left corner of the image, and the centre of pixel (x, y)
+// at (x+0.5, y+0.5)
+//
+type AffineMatrix [2][3]float64
+
+//
+// RotateMode decides what happens to the canvas when we rotate an image by an arbitrary angle...
+//
+type RotateMode int
+
+const (
+	// RotateExpand grows the canvas so that the whole of the rotated image fits on it
+	RotateExpand RotateMode = iota
+
+	// RotateCrop keeps the canvas the same size as the original image (cropping the corners off)
+	RotateCrop
+)
+
+//
+// IdentityAffineMatrix returns the affine matrix that doesn't move anything...
+//
+func IdentityAffineMatrix() AffineMatrix {
+	return AffineMatrix{
+		{1, 0, 0},
+		{0, 1, 0},
+	}
+}
+
+//
+// TranslationMatrix returns an affine matrix that moves points by (tx, ty)
+//
+func TranslationMatrix(tx, ty float64) AffineMatrix {
+	return AffineMatrix{
+		{1, 0, tx},
+		{0, 1, ty},
+	}
+}
+
+//
+// ScaleMatrix returns an affine matrix that scales points by sx horizontally and sy vertically (about the origin)
+//
+func ScaleMatrix(sx, sy float64) AffineMatrix {
+	return AffineMatrix{
+		{sx, 0, 0},
+		{0, sy, 0},
+	}
+}
+
+//
+// RotationMatrix returns an affine matrix that rotates points clockwise (as y goes down the image) by the given
+// number of degrees (about the origin)
+//
+func RotationMatrix(degrees float64) AffineMatrix {
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+
+	return AffineMatrix{
+		{cos, -sin, 0},
+		{sin, cos, 0},
+	}
+}
+
+//
+// ShearMatrix returns an affine matrix that shears points by shx horizontally and shy vertically
+//
+func ShearMatrix(shx, shy float64) AffineMatrix {
+	return AffineMatrix{
+		{1, shx, 0},
+		{shy, 1, 0},
+	}
+}
+
+//
+// Multiply returns the matrix that applies n first, and then m (ie. m x n)
+//
+func (m AffineMatrix) Multiply(n AffineMatrix) AffineMatrix {
+	return AffineMatrix{
+		{
+			m[0][0]*n[0][0] + m[0][1]*n[1][0],
+			m[0][0]*n[0][1] + m[0][1]*n[1][1],
+			m[0][0]*n[0][2] + m[0][1]*n[1][2] + m[0][2],
+		},
+		{
+			m[1][0]*n[0][0] + m[1][1]*n[1][0],
+			m[1][0]*n[0][1] + m[1][1]*n[1][1],
+			m[1][0]*n[0][2] + m[1][1]*n[1][2] + m[1][2],
+		},
+	}
+}
+
+//
+// Inverse returns the matrix that undoes m. It will log a fatal error if m can't be inverted (eg. it scales
+// everything down to a line or a point)
+//
+func (m AffineMatrix) Inverse() AffineMatrix {
+	det := m[0][0]*m[1][1] - m[0][1]*m[1][0]
+	if math.Abs(det) < 1e-12 {
+		log.Fatalln("The affine matrix can not be inverted:", m)
+	}
+
+	a := m[1][1] / det
+	b := -m[0][1] / det
+	d := -m[1][0] / det
+	e := m[0][0] / det
+
+	return AffineMatrix{
+		{a, b, -(a*m[0][2] + b*m[1][2])},
+		{d, e, -(d*m[0][2] + e*m[1][2])},
+	}
+}
+
+//
+// Apply returns where the point (x, y) ends up after it has been transformed by m
+//
+func (m AffineMatrix) Apply(x, y float64) (float64, float64) {
+	return m[0][0]*x + m[0][1]*y + m[0][2], m[1][0]*x + m[1][1]*y + m[1][2]
+}
+
+//
+// FlipHorizontal returns a new ImageMatrix mirrored left to right
+//
+func (im ImageMatrix) FlipHorizontal() ImageMatrix {
+	width := im.GetWidth()
+	newMatrix := ImageMatrix{}
+
+	for x := width - 1; x >= 0; x-- {
+		newMatrix = append(newMatrix, append(ImageRow{}, im[x]...))
+	}
+
+	return newMatrix
+}
+
+//
+// FlipVertical returns a new ImageMatrix mirrored top to bottom
+//
+func (im ImageMatrix) FlipVertical() ImageMatrix {
+	height := im.GetHeight()
+	newMatrix := ImageMatrix{}
+
+	for _, column := range im {
+		newColumn := make(ImageRow, height)
+
+		for y := range column {
+			newColumn[height-1-y] = column[y]
+		}
+
+		newMatrix = append(newMatrix, newColumn)
+	}
+
+	return newMatrix
+}
+
+//
+// Transpose returns a new ImageMatrix mirrored along the top-left to bottom-right diagonal (ie. x and y are swapped)
+//
+func (im ImageMatrix) Transpose() ImageMatrix {
+	width := im.GetWidth()
+	height := im.GetHeight()
+	newMatrix := ImageMatrix{}
+
+	for y := 0; y < height; y++ {
+		newColumn := make(ImageRow, width)
+
+		for x := 0; x < width; x++ {
+			newColumn[x] = im[x][y]
+		}
+
+		newMatrix = append(newMatrix, newColumn)
+	}
+
+	return newMatrix
+}
+
+//
+// Rotate90 returns a new ImageMatrix rotated 90 degrees clockwise
+//
+func (im ImageMatrix) Rotate90() ImageMatrix {
+	return im.Transpose().FlipHorizontal()
+}
+
+//
+// Rotate180 returns a new ImageMatrix rotated 180 degrees
+//
+func (im ImageMatrix) Rotate180() ImageMatrix {
+	width := im.GetWidth()
+	height := im.GetHeight()
+	newMatrix := ImageMatrix{}
+
+	for x := width - 1; x >= 0; x-- {
+		newColumn := make(ImageRow, height)
+
+		for y := 0; y < height; y++ {
+			newColumn[height-1-y] = im[x][y]
+		}
+
+		newMatrix = append(newMatrix, newColumn)
+	}
+
+	return newMatrix
+}
+
+//
+// Rotate270 returns a new ImageMatrix rotated 270 degrees clockwise (ie. 90 degrees anti-clockwise)
+//
+func (im ImageMatrix) Rotate270() ImageMatrix {
+	return im.Transpose().FlipVertical()
+}
+
+//
+// Rotate returns a new ImageMatrix rotated clockwise by an arbitrary number of degrees (about the centre of the
+// image). The filter decides how we interpolate between the source pixels, and any part of the new canvas that
+// isn't covered by the rotated image is filled with the background colour.
+//
+func (im ImageMatrix) Rotate(degrees float64, filter ResampleFilter, background color.RGBA, mode RotateMode) ImageMatrix {
+	if mode == RotateExpand {
+		return im.AffineTransform(RotationMatrix(degrees), filter, background)
+	}
+
+	width := im.GetWidth()
+	height := im.GetHeight()
+	cx := float64(width) / 2
+	cy := float64(height) / 2
+
+	// move the centre to the origin, rotate, and then move it back again...
+	m := TranslationMatrix(cx, cy).Multiply(RotationMatrix(degrees)).Multiply(TranslationMatrix(-cx, -cy))
+
+	return im.warp(width, height, affineMapping(m.Inverse(), 0, 0), filter, background)
+}
+
+//
+// AffineTransform returns a new ImageMatrix with the affine transformation applied to it. The new canvas is
+// made large enough to hold the whole of the transformed image (so translations in the matrix don't have an
+// effect on the result, other than through sub-pixel positioning). The filter decides how we interpolate
+// between the source pixels, and any part of the canvas that isn't covered by the image is set to background.
+//
+func (im ImageMatrix) AffineTransform(m AffineMatrix, filter ResampleFilter, background color.RGBA) ImageMatrix {
+	width := float64(im.GetWidth())
+	height := float64(im.GetHeight())
+
+	// Work out where the corners of the image end up, so that we know how big the new canvas needs to be
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, corner := range [][2]float64{{0, 0}, {width, 0}, {0, height}, {width, height}} {
+		x, y := m.Apply(corner[0], corner[1])
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+
+	// (the small tolerance stops floating point noise from adding an extra row/column of background)
+	newWidth := maxInt(1, int(math.Ceil(maxX-minX-1e-6)))
+	newHeight := maxInt(1, int(math.Ceil(maxY-minY-1e-6)))
+
+	return im.warp(newWidth, newHeight, affineMapping(m.Inverse(), minX, minY), filter, background)
+}
+
+//
+// affineMapping returns a function that maps the centre of the destination pixel (x, y) back to a point in the
+// source image (using the inverse transformation); originX/originY is where the top left corner of the
+// destination canvas lies in the transformed space.
+//
+func affineMapping(inverse AffineMatrix, originX, originY float64) func(int, int) (float64, float64, bool) {
+	return func(x, y int) (float64, float64, bool) {
+		u, v := inverse.Apply(float64(x)+0.5+originX, float64(y)+0.5+originY)
+		return u, v, true
+	}
+}
+
+//
+// warp builds a new width x height ImageMatrix where every pixel is sampled from the source image at the
+// position the mapping function returns for it (the mapping returns false if there is no sensible source
+// position, in which case the pixel is set to the background colour).
+// This is the generic resampling primitive that the affine (and perspective) transforms are built on.
+//
+func (im ImageMatrix) warp(width, height int, mapping func(int, int) (float64, float64, bool), filter ResampleFilter, background color.RGBA) ImageMatrix {
+	newMatrix := ImageMatrix{}
+
+	for x := 0; x < width; x++ {
+		column := make(ImageRow, height)
+
+		for y := 0; y < height; y++ {
+			u, v, ok := mapping(x, y)
+			if !ok {
+				column[y] = background
+				continue
+			}
+
+			column[y] = im.sample(u, v, filter, background)
+		}
+
+		newMatrix = append(newMatrix, column)
+	}
+
+	return newMatrix
+}
+
+//
+// sample returns the (interpolated) colour of the image at the continuous position (u, v) where (0, 0) is the
+// top left corner of the image. Any filter taps that fall outside the image pick up the background colour,
+// which gives us nicely anti-aliased edges when rotating, etc...
+//
+func (im ImageMatrix) sample(u, v float64, filter ResampleFilter, background color.RGBA) color.RGBA {
+	width := im.GetWidth()
+	height := im.GetHeight()
+
+	// move into "pixel index" space, where the centre of pixel (i, j) is at (i, j)
+	u -= 0.5
+	v -= 0.5
+
+	support := filter.support()
+	if u < -support || v < -support || u > float64(width-1)+support || v > float64(height-1)+support {
+		return background
+	}
+
+	if filter == NearestNeighbour || filter == Box {
+		i := int(math.Floor(u + 0.5))
+		j := int(math.Floor(v + 0.5))
+
+		if i < 0 || j < 0 || i >= width || j >= height {
+			return background
+		}
+
+		return im[i][j]
+	}
+
+	startX := int(math.Floor(u-support)) + 1
+	startY := int(math.Floor(v-support)) + 1
+	endX := int(math.Ceil(u + support))
+	endY := int(math.Ceil(v + support))
+
+	var r, g, b, a, total float64
+
+	for i := startX; i < endX; i++ {
+		wx := filter.weight(float64(i) - u)
+		if wx == 0 {
+			continue
+		}
+
+		for j := startY; j < endY; j++ {
+			w := wx * filter.weight(float64(j)-v)
+			if w == 0 {
+				continue
+			}
+
+			c := background
+			if i >= 0 && j >= 0 && i < width && j < height {
+				c = im[i][j]
+			}
+
+			r += float64(c.R) * w
+			g += float64(c.G) * w
+			b += float64(c.B) * w
+			a += float64(c.A) * w
+			total += w
+		}
+	}
+
+	if total == 0 {
+		return background
+	}
+
+	return premultipliedRGBA(r/total, g/total, b/total, a/total)
+}
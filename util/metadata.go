@@ -0,0 +1,512 @@
+package util
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+//
+// Metadata is the extra information stored in an image file alongside the picture itself, kept as it was in the
+// file so that it can be written back out again unchanged...
+//
+type Metadata struct {
+	// EXIF is the raw EXIF block (from the TIFF header onwards); camera settings, copyright, GPS position, etc
+	EXIF []byte
+
+	// XMP is the XMP packet (an XML document of, eg. copyright and editing history)
+	XMP []byte
+
+	// ICC is the (uncompressed) ICC colour profile; without it the colours of eg. an Adobe RGB photo come out wrong
+	ICC []byte
+
+	// Text is the png text chunks (tEXt, zTXt, iTXt) by keyword; a jpeg comment is kept under "Comment". As jpeg
+	// comments have no keywords, any other text saved in a jpeg is written as a comment of it's own ("Keyword: text")
+	// and so comes back under "Comment" when it's read
+	Text map[string]string
+}
+
+const (
+	jpegEXIFHeader = "Exif\x00\x00"
+	jpegXMPHeader  = "http://ns.adobe.com/xap/1.0/\x00"
+	jpegICCHeader  = "ICC_PROFILE\x00"
+	pngSignature   = "\x89PNG\r\n\x1a\n"
+	pngXMPKeyword  = "XML:com.adobe.xmp"
+	jpegComment    = "Comment"
+
+	// the most data a jpeg segment can hold (the 2 byte length includes itself)
+	jpegMaxSegment = 65533
+)
+
+//
+// The EXIF tags (and value types) we change...
+//
+const (
+	exifTagOrientation     = 0x0112
+	exifTagExifIFD         = 0x8769
+	exifTagPixelXDimension = 0xA002
+	exifTagPixelYDimension = 0xA003
+
+	exifTypeShort = 3
+	exifTypeLong  = 4
+)
+
+//
+// IsEmpty returns true if there is no metadata at all
+//
+func (m Metadata) IsEmpty() bool {
+	return len(m.EXIF) == 0 && len(m.XMP) == 0 && len(m.ICC) == 0 && len(m.Text) == 0
+}
+
+//
+// Stripped returns a copy of the metadata with everything that could say something about the photographer (the
+// EXIF with it's GPS position and camera serial numbers, the XMP, and the text) taken out. The ICC profile is
+// kept, as it's only about the colours, and they would come out wrong without it.
+//
+func (m Metadata) Stripped() Metadata {
+	return Metadata{ICC: m.ICC}
+}
+
+//
+// WithOrientationReset returns a copy of the metadata with the EXIF orientation set to 1 (the right way up); once
+// the picture has been turned the right way up, leaving the old orientation in would get it turned again by
+// anything that opens the file
+//
+func (m Metadata) WithOrientationReset() Metadata {
+	return m.withEXIFEntries(func(exif []byte, order binary.ByteOrder, tag uint16, entry int) {
+		// (the orientation is a single SHORT, so it's stored in the entry itself)
+		if tag == exifTagOrientation {
+			order.PutUint16(exif[entry+8:], 1)
+		}
+	})
+}
+
+//
+// WithDimensions returns a copy of the metadata with the EXIF pixel dimensions (PixelXDimension and
+// PixelYDimension) set to width and height, so that they still match the image after it's been resized, cropped
+// or rotated. The embed functions do this for the image they are embedding into.
+//
+func (m Metadata) WithDimensions(width, height int) Metadata {
+	return m.withEXIFEntries(func(exif []byte, order binary.ByteOrder, tag uint16, entry int) {
+		value := uint32(width)
+		if tag == exifTagPixelYDimension {
+			value = uint32(height)
+		} else if tag != exifTagPixelXDimension {
+			return
+		}
+
+		// (the dimension can be a SHORT or a LONG; either way it's stored in the entry itself)
+		if order.Uint16(exif[entry+2:]) == exifTypeShort && value <= 0xFFFF {
+			order.PutUint16(exif[entry+8:], uint16(value))
+			order.PutUint16(exif[entry+10:], 0)
+		} else {
+			order.PutUint16(exif[entry+2:], exifTypeLong)
+			order.PutUint32(exif[entry+8:], value)
+		}
+	})
+}
+
+//
+// withEXIFEntries returns a copy of the metadata with a copy of the EXIF block, after calling change for every
+// entry (with the offset of the 12 byte entry) of the first IFD and the EXIF IFD, so it can change their values
+//
+func (m Metadata) withEXIFEntries(change func(exif []byte, order binary.ByteOrder, tag uint16, entry int)) Metadata {
+	if len(m.EXIF) < 8 {
+		return m
+	}
+
+	var order binary.ByteOrder = binary.BigEndian
+	if string(m.EXIF[:2]) == "II" {
+		order = binary.LittleEndian
+	}
+
+	exif := append([]byte{}, m.EXIF...)
+
+	var walk func(offset int, depth int)
+	walk = func(offset int, depth int) {
+		if offset < 8 || offset+2 > len(exif) || depth > 1 {
+			return
+		}
+
+		for n := 0; n < int(order.Uint16(exif[offset:])); n++ {
+			entry := offset + 2 + n*12
+			if entry+12 > len(exif) {
+				break
+			}
+
+			tag := order.Uint16(exif[entry:])
+			if tag == exifTagExifIFD {
+				walk(int(order.Uint32(exif[entry+8:])), depth+1)
+				continue
+			}
+
+			change(exif, order, tag, entry)
+		}
+	}
+
+	walk(int(order.Uint32(exif[4:])), 0)
+
+	m.EXIF = exif
+	return m
+}
+
+//
+// ReadMetadata returns the metadata from a jpeg or png file's data (anything else has no metadata we can read)
+//
+func ReadMetadata(data []byte) Metadata {
+	if len(data) > 2 && data[0] == 0xFF && data[1] == 0xD8 {
+		return readJPEGMetadata(data)
+	}
+
+	if bytes.HasPrefix(data, []byte(pngSignature)) {
+		return readPNGMetadata(data)
+	}
+
+	return Metadata{}
+}
+
+//
+// readJPEGMetadata walks the segments at the start of a jpeg (up to the image data) picking out the metadata
+//
+func readJPEGMetadata(data []byte) Metadata {
+	m := Metadata{}
+	iccChunks := map[byte][]byte{}
+
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+
+		segment := data[i+4 : end]
+		switch {
+		case marker == 0xE1 && bytes.HasPrefix(segment, []byte(jpegEXIFHeader)):
+			m.EXIF = append([]byte{}, segment[len(jpegEXIFHeader):]...)
+		case marker == 0xE1 && bytes.HasPrefix(segment, []byte(jpegXMPHeader)):
+			m.XMP = append([]byte{}, segment[len(jpegXMPHeader):]...)
+		case marker == 0xE2 && bytes.HasPrefix(segment, []byte(jpegICCHeader)) && len(segment) > len(jpegICCHeader)+2:
+			// big profiles are split over several segments, each numbered (from 1)
+			iccChunks[segment[len(jpegICCHeader)]] = segment[len(jpegICCHeader)+2:]
+		case marker == 0xFE:
+			m.addText(jpegComment, string(segment))
+		}
+
+		i = end
+	}
+
+	for n := byte(1); iccChunks[n] != nil; n++ {
+		m.ICC = append(m.ICC, iccChunks[n]...)
+	}
+
+	return m
+}
+
+//
+// readPNGMetadata walks the chunks of a png picking out the metadata
+//
+func readPNGMetadata(data []byte) Metadata {
+	m := Metadata{}
+
+	for i := len(pngSignature); i+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		chunkType := string(data[i+4 : i+8])
+		end := i + 8 + length
+		if length < 0 || end+4 > len(data) {
+			break
+		}
+
+		chunk := data[i+8 : end]
+		switch chunkType {
+		case "eXIf":
+			m.EXIF = append([]byte{}, chunk...)
+		case "iCCP":
+			// profile name, nul, compression method (always 0, zlib), compressed profile
+			if name := bytes.IndexByte(chunk, 0); name >= 0 && name+2 <= len(chunk) {
+				m.ICC = inflate(chunk[name+2:])
+			}
+		case "tEXt":
+			if keyword := bytes.IndexByte(chunk, 0); keyword >= 0 {
+				m.addText(string(chunk[:keyword]), latin1ToString(chunk[keyword+1:]))
+			}
+		case "zTXt":
+			if keyword := bytes.IndexByte(chunk, 0); keyword >= 0 && keyword+2 <= len(chunk) {
+				m.addText(string(chunk[:keyword]), latin1ToString(inflate(chunk[keyword+2:])))
+			}
+		case "iTXt":
+			// keyword, nul, compressed flag, compression method, language tag, nul, translated keyword, nul, text
+			parts := bytes.SplitN(chunk, []byte{0}, 2)
+			if len(parts) < 2 || len(parts[1]) < 2 {
+				break
+			}
+
+			compressed := parts[1][0] == 1
+			rest := bytes.SplitN(parts[1][2:], []byte{0}, 3)
+			if len(rest) < 3 {
+				break
+			}
+
+			text := rest[2]
+			if compressed {
+				text = inflate(text)
+			}
+
+			if string(parts[0]) == pngXMPKeyword {
+				m.XMP = append([]byte{}, text...)
+			} else {
+				m.addText(string(parts[0]), string(text))
+			}
+		case "IEND":
+			return m
+		}
+
+		i = end + 4
+	}
+
+	return m
+}
+
+//
+// EmbedMetadataInJPEG returns the jpeg data with the metadata added (just after the JFIF header, if there is one,
+// as the JFIF header has to come first). An EXIF block or XMP packet that is too big for a jpeg segment is left
+// out; the ICC profile is split over as many segments as it needs. The text goes in comment segments; "Comment"
+// first as it is, then the rest in keyword order as "Keyword: text" (any too big for a segment are left out).
+//
+func EmbedMetadataInJPEG(data []byte, m Metadata) []byte {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 || m.IsEmpty() {
+		return data
+	}
+
+	if width, height, ok := jpegDimensions(data); ok {
+		m = m.WithDimensions(width, height)
+	}
+
+	segments := &bytes.Buffer{}
+	writeSegment := func(marker byte, parts ...[]byte) {
+		length := 2
+		for _, part := range parts {
+			length += len(part)
+		}
+
+		segments.Write([]byte{0xFF, marker, byte(length >> 8), byte(length)})
+		for _, part := range parts {
+			segments.Write(part)
+		}
+	}
+
+	if len(m.EXIF) > 0 && len(jpegEXIFHeader)+len(m.EXIF) <= jpegMaxSegment {
+		writeSegment(0xE1, []byte(jpegEXIFHeader), m.EXIF)
+	}
+
+	if len(m.XMP) > 0 && len(jpegXMPHeader)+len(m.XMP) <= jpegMaxSegment {
+		writeSegment(0xE1, []byte(jpegXMPHeader), m.XMP)
+	}
+
+	if len(m.ICC) > 0 {
+		chunkSize := jpegMaxSegment - len(jpegICCHeader) - 2
+		count := (len(m.ICC) + chunkSize - 1) / chunkSize
+		for n := 0; n < count && count < 256; n++ {
+			chunk := m.ICC[n*chunkSize : minInt(len(m.ICC), (n+1)*chunkSize)]
+			writeSegment(0xE2, []byte(jpegICCHeader), []byte{byte(n + 1), byte(count)}, chunk)
+		}
+	}
+
+	if comment, ok := m.Text[jpegComment]; ok && len(comment) <= jpegMaxSegment {
+		writeSegment(0xFE, []byte(comment))
+	}
+
+	for _, keyword := range sortedKeywords(m.Text) {
+		if comment := keyword + ": " + m.Text[keyword]; keyword != jpegComment && len(comment) <= jpegMaxSegment {
+			writeSegment(0xFE, []byte(comment))
+		}
+	}
+
+	// skip past the JFIF (APP0) segment if the encoder wrote one...
+	insertAt := 2
+	if len(data) >= 6 && data[2] == 0xFF && data[3] == 0xE0 {
+		insertAt = 4 + int(binary.BigEndian.Uint16(data[4:]))
+	}
+	if insertAt > len(data) {
+		return data
+	}
+
+	newData := append([]byte{}, data[:insertAt]...)
+	newData = append(newData, segments.Bytes()...)
+	return append(newData, data[insertAt:]...)
+}
+
+//
+// jpegDimensions returns the width and height from the jpeg's start of frame segment
+//
+func jpegDimensions(data []byte) (int, int, bool) {
+	for i := 2; i+9 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+
+		// SOF0 to SOF15, apart from DHT (C4), JPG (C8) and DAC (CC) which share the range
+		if marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC {
+			return int(binary.BigEndian.Uint16(data[i+7:])), int(binary.BigEndian.Uint16(data[i+5:])), true
+		}
+
+		i += 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+	}
+
+	return 0, 0, false
+}
+
+//
+// EmbedMetadataInPNG returns the png data with the metadata added as chunks just after the header (IHDR) chunk;
+// iCCP for the profile, eXIf for the EXIF, an iTXt for the XMP, and tEXt (or iTXt, if they aren't plain ascii)
+// for the text
+//
+func EmbedMetadataInPNG(data []byte, m Metadata) []byte {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) || len(data) < 33 || m.IsEmpty() {
+		return data
+	}
+
+	// (the dimensions are the first thing in the IHDR chunk)
+	m = m.WithDimensions(int(binary.BigEndian.Uint32(data[16:])), int(binary.BigEndian.Uint32(data[20:])))
+
+	chunks := &bytes.Buffer{}
+	writeChunk := func(chunkType string, parts ...[]byte) {
+		chunk := []byte(chunkType)
+		for _, part := range parts {
+			chunk = append(chunk, part...)
+		}
+
+		length := make([]byte, 4)
+		binary.BigEndian.PutUint32(length, uint32(len(chunk)-4))
+		crc := make([]byte, 4)
+		binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(chunk))
+
+		chunks.Write(length)
+		chunks.Write(chunk)
+		chunks.Write(crc)
+	}
+
+	if len(m.ICC) > 0 {
+		writeChunk("iCCP", []byte("ICC Profile\x00\x00"), deflate(m.ICC))
+	}
+
+	if len(m.EXIF) > 0 {
+		writeChunk("eXIf", m.EXIF)
+	}
+
+	if len(m.XMP) > 0 {
+		writeChunk("iTXt", []byte(pngXMPKeyword+"\x00\x00\x00\x00\x00"), m.XMP)
+	}
+
+	for _, keyword := range sortedKeywords(m.Text) {
+		text := m.Text[keyword]
+		if isASCII(text) {
+			writeChunk("tEXt", []byte(keyword+"\x00"), []byte(text))
+		} else {
+			writeChunk("iTXt", []byte(keyword+"\x00\x00\x00\x00\x00"), []byte(text))
+		}
+	}
+
+	// the signature (8 bytes) and the IHDR chunk (4 byte length, 4 byte type, 13 bytes of data, 4 byte crc)
+	// always come first
+	insertAt := 33
+	newData := append([]byte{}, data[:insertAt]...)
+	newData = append(newData, chunks.Bytes()...)
+	return append(newData, data[insertAt:]...)
+}
+
+//
+// addText adds a text entry to the metadata (joining it on to any text already there with the same keyword)
+//
+func (m *Metadata) addText(keyword, text string) {
+	if m.Text == nil {
+		m.Text = map[string]string{}
+	}
+
+	if existing, ok := m.Text[keyword]; ok {
+		text = existing + "\n" + text
+	}
+
+	m.Text[keyword] = text
+}
+
+//
+// sortedKeywords returns the keywords of the text in order (so the same metadata is always written out the same)
+//
+func sortedKeywords(text map[string]string) []string {
+	keywords := []string{}
+	for keyword := range text {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+
+	return keywords
+}
+
+//
+// inflate returns the zlib compressed data uncompressed (or nil if it's not valid)
+//
+func inflate(data []byte) []byte {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+
+	inflated, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil
+	}
+
+	return inflated
+}
+
+//
+// deflate returns the data zlib compressed
+//
+func deflate(data []byte) []byte {
+	buffer := &bytes.Buffer{}
+	writer := zlib.NewWriter(buffer)
+	writer.Write(data)
+	writer.Close()
+
+	return buffer.Bytes()
+}
+
+//
+// latin1ToString converts latin-1 text (what tEXt and zTXt chunks hold) to a (utf-8) string
+//
+func latin1ToString(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+
+	return string(runes)
+}
+
+//
+// isASCII returns true if the text is plain printable ascii (plus newlines), so it can go in a tEXt chunk as is
+//
+func isASCII(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool {
+		return r > 126 || (r < 32 && r != '\n')
+	}) == -1
+}
+
+//
+// minInt returns the smaller of a and b
+//
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,261 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"reflect"
+	"testing"
+)
+
+//
+// The offsets of the values in the EXIF blocks testEXIF builds; the orientation in the first IFD, and the
+// dimensions (their types, then their values) in the EXIF IFD
+//
+const (
+	testOrientation = 18
+	testXType       = 42
+	testX           = 48
+	testYType       = 54
+	testY           = 60
+)
+
+//
+// testEXIF returns an EXIF block with an orientation in the first IFD, and an EXIF IFD holding the width as a
+// SHORT and the height as a LONG
+//
+func testEXIF(order binary.ByteOrder, orientation uint16, width uint16, height uint32) []byte {
+	exif := make([]byte, 70)
+	if order == binary.LittleEndian {
+		copy(exif, "II*\x00")
+	} else {
+		copy(exif, "MM\x00*")
+	}
+	order.PutUint32(exif[4:], 8)
+
+	entry := func(at int, tag, valueType uint16) {
+		order.PutUint16(exif[at:], tag)
+		order.PutUint16(exif[at+2:], valueType)
+		order.PutUint32(exif[at+4:], 1)
+	}
+
+	// the first IFD (2 entries, then a 0 offset to the next IFD) at 8, and the EXIF IFD after it at 38
+	order.PutUint16(exif[8:], 2)
+	entry(10, exifTagOrientation, exifTypeShort)
+	order.PutUint16(exif[testOrientation:], orientation)
+	entry(22, exifTagExifIFD, exifTypeLong)
+	order.PutUint32(exif[30:], 38)
+
+	order.PutUint16(exif[38:], 2)
+	entry(40, exifTagPixelXDimension, exifTypeShort)
+	order.PutUint16(exif[testX:], width)
+	entry(52, exifTagPixelYDimension, exifTypeLong)
+	order.PutUint32(exif[testY:], height)
+
+	return exif
+}
+
+//
+// testMetadata returns metadata with a bit of everything in it
+//
+func testMetadata() Metadata {
+	return Metadata{
+		EXIF: testEXIF(binary.LittleEndian, 6, 4000, 3000),
+		XMP:  []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF/></x:xmpmeta>`),
+		ICC:  bytes.Repeat([]byte("profile "), 100),
+		Text: map[string]string{"Comment": "a comment", "Title": "a title", "Author": "Zoë"},
+	}
+}
+
+//
+// encodeWithMetadata encodes a 30x20 image in the format with the metadata, failing the test if it can't
+//
+func encodeWithMetadata(t *testing.T, format Format, m Metadata) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 30, 20))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := Encode(buffer, img, format, &SaveOptions{Metadata: m}); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	m := testMetadata()
+
+	for _, test := range []struct {
+		format Format
+		text   map[string]string
+	}{
+		{FormatPNG, m.Text},
+		// (only the comment comes back with it's keyword from a jpeg; the rest are comments of their own)
+		{FormatJPEG, map[string]string{"Comment": "a comment\nAuthor: Zoë\nTitle: a title"}},
+	} {
+		t.Run(string(test.format), func(t *testing.T) {
+			got := ReadMetadata(encodeWithMetadata(t, test.format, m))
+
+			if want := m.WithDimensions(30, 20).EXIF; !bytes.Equal(got.EXIF, want) {
+				t.Errorf("got EXIF % x, want % x", got.EXIF, want)
+			}
+			if !bytes.Equal(got.XMP, m.XMP) {
+				t.Errorf("got XMP %q, want %q", got.XMP, m.XMP)
+			}
+			if !bytes.Equal(got.ICC, m.ICC) {
+				t.Errorf("got a %v byte ICC profile, want the %v byte one", len(got.ICC), len(m.ICC))
+			}
+			if !reflect.DeepEqual(got.Text, test.text) {
+				t.Errorf("got text %q, want %q", got.Text, test.text)
+			}
+		})
+	}
+
+	if got := ReadMetadata(encodeWithMetadata(t, FormatPNG, Metadata{})); !got.IsEmpty() {
+		t.Errorf("got metadata %+v from a png without any", got)
+	}
+}
+
+func TestMetadataLargeICC(t *testing.T) {
+	icc := make([]byte, 2*jpegMaxSegment+1000)
+	for i := range icc {
+		icc[i] = uint8(i * 7)
+	}
+
+	data := encodeWithMetadata(t, FormatJPEG, Metadata{ICC: icc})
+	if segments := bytes.Count(data, []byte(jpegICCHeader)); segments != 3 {
+		t.Errorf("the ICC profile was split into %v segments, want 3", segments)
+	}
+
+	if got := ReadMetadata(data).ICC; !bytes.Equal(got, icc) {
+		t.Errorf("got a %v byte ICC profile back, want the %v byte one", len(got), len(icc))
+	}
+
+	if got := ReadMetadata(encodeWithMetadata(t, FormatPNG, Metadata{ICC: icc})).ICC; !bytes.Equal(got, icc) {
+		t.Errorf("got a %v byte ICC profile back from the png, want the %v byte one", len(got), len(icc))
+	}
+}
+
+func TestMetadataPNGiTXt(t *testing.T) {
+	xmp := []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/">Zoë</x:xmpmeta>`)
+
+	// the XMP (and text that isn't plain ascii) goes in iTXt chunks
+	data := encodeWithMetadata(t, FormatPNG, Metadata{XMP: xmp, Text: map[string]string{"Author": "Zoë"}})
+	if chunks := bytes.Count(data, []byte("iTXt")); chunks != 2 {
+		t.Errorf("got %v iTXt chunks, want 2", chunks)
+	}
+
+	got := ReadMetadata(data)
+	if !bytes.Equal(got.XMP, xmp) {
+		t.Errorf("got XMP %q, want %q", got.XMP, xmp)
+	}
+	if got.Text["Author"] != "Zoë" || len(got.Text) != 1 {
+		t.Errorf("got text %q, want just the author", got.Text)
+	}
+
+	// a compressed iTXt (with a language and translated keyword, which are skipped over) is read too
+	compressed := encodeWithMetadata(t, FormatPNG, Metadata{})
+	chunk := append([]byte(pngXMPKeyword+"\x00\x01\x00en\x00XMP\x00"), deflate(xmp)...)
+	compressed = append(compressed[:33], append(pngChunk("iTXt", chunk), compressed[33:]...)...)
+
+	if got := ReadMetadata(compressed).XMP; !bytes.Equal(got, xmp) {
+		t.Errorf("got XMP %q from a compressed iTXt chunk, want %q", got, xmp)
+	}
+}
+
+//
+// pngChunk returns a png chunk; the length, the type and data, and the crc of the type and data
+//
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := make([]byte, 4, 12+len(data))
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	chunk = append(append(chunk, chunkType...), data...)
+
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(chunk[4:]))
+
+	return append(chunk, crc...)
+}
+
+func TestMetadataWithDimensionsAndOrientation(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		t.Run(order.String(), func(t *testing.T) {
+			original := testEXIF(order, 8, 4000, 3000)
+			m := Metadata{EXIF: append([]byte{}, original...)}
+
+			reset := m.WithOrientationReset().EXIF
+			if orientation := order.Uint16(reset[testOrientation:]); orientation != 1 {
+				t.Errorf("got orientation %v after resetting it, want 1", orientation)
+			}
+
+			resized := m.WithDimensions(640, 480).EXIF
+			if width := order.Uint16(resized[testX:]); width != 640 || order.Uint16(resized[testXType:]) != exifTypeShort {
+				t.Errorf("got width %v, want 640 (still a SHORT)", width)
+			}
+			if height := order.Uint32(resized[testY:]); height != 480 || order.Uint16(resized[testYType:]) != exifTypeLong {
+				t.Errorf("got height %v, want 480 (still a LONG)", height)
+			}
+			if orientation := order.Uint16(resized[testOrientation:]); orientation != 8 {
+				t.Errorf("changing the dimensions changed the orientation to %v", orientation)
+			}
+
+			// a width too big for a SHORT turns it into a LONG
+			wide := m.WithDimensions(70000, 480).EXIF
+			if width := order.Uint32(wide[testX:]); width != 70000 || order.Uint16(wide[testXType:]) != exifTypeLong {
+				t.Errorf("got width %v (type %v), want 70000 as a LONG", width, order.Uint16(wide[testXType:]))
+			}
+
+			if !bytes.Equal(m.EXIF, original) {
+				t.Error("the EXIF block the changes were made to was changed itself")
+			}
+		})
+	}
+}
+
+func TestMetadataTruncated(t *testing.T) {
+	m := testMetadata()
+	m.ICC = bytes.Repeat([]byte{1, 2, 3}, jpegMaxSegment)
+
+	// (none of these can fail, but none of them can panic either, whatever they're given)
+	for _, format := range []Format{FormatPNG, FormatJPEG} {
+		data := encodeWithMetadata(t, format, m)
+		for n := 0; n < len(data); n += 1 + n/1000 {
+			ReadMetadata(data[:n])
+			EmbedMetadataInJPEG(data[:n], m)
+			EmbedMetadataInPNG(data[:n], m)
+		}
+	}
+
+	for n := 0; n < len(m.EXIF); n++ {
+		truncated := Metadata{EXIF: m.EXIF[:n]}
+		truncated.WithOrientationReset()
+		truncated.WithDimensions(10, 10)
+	}
+
+	// an IFD offset pointing past the end, or (for the EXIF IFD) back at the first IFD
+	for _, offset := range []uint32{0xFFFFFFF0, 8} {
+		exif := testEXIF(binary.BigEndian, 6, 1, 1)
+		binary.BigEndian.PutUint32(exif[30:], offset)
+		Metadata{EXIF: exif}.WithDimensions(10, 10)
+	}
+}
+
+func TestEmbedMetadataInJPEGAfterJFIF(t *testing.T) {
+	jfif := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x06, 'J', 'F', 'I', 'F', 0xFF, 0xD9}
+	data := EmbedMetadataInJPEG(jfif, Metadata{Text: map[string]string{"Comment": "hi"}})
+
+	want := append(append([]byte{}, jfif[:10]...), 0xFF, 0xFE, 0x00, 0x04, 'h', 'i', 0xFF, 0xD9)
+	if !bytes.Equal(data, want) {
+		t.Errorf("got % x, want % x", data, want)
+	}
+
+	// a JFIF segment that runs off the end is left alone
+	if data := EmbedMetadataInJPEG(jfif[:8], testMetadata()); !bytes.Equal(data, jfif[:8]) {
+		t.Errorf("got % x, want the truncated jpeg unchanged", data)
+	}
+}
@@ -2,42 +2,168 @@ package util
 
 import (
 	"image"
+	"image/color"
+	"image/draw"
 	"image/gif"
-	"image/jpeg"
-	"image/png"
-	"os"
+	"io"
+
+	"../formats/netpbm"
 )
 
 //
 // SaveImageToFileAsPNG will save an image to the filesystem as a png...
 //
 func SaveImageToFileAsPNG(filename string, image image.Image) {
-	outfile, err := os.Create(filename)
-	CheckError(err)
-	defer outfile.Close()
-
-	png.Encode(outfile, image)
+	CheckError(Save(filename, image, &SaveOptions{Format: FormatPNG}))
 }
 
 //
 // SaveImageToFileAsJPG will save an image to the filesystem as a jpg...
 //
 func SaveImageToFileAsJPG(filename string, image image.Image) {
-	outfile, err := os.Create(filename)
-	CheckError(err)
-	defer outfile.Close()
+	CheckError(Save(filename, image, &SaveOptions{Format: FormatJPEG}))
+}
+
+//
+// SaveImageToFileAsPNGWithMetadata will save an image to the filesystem as a png, with the metadata (eg. from
+// monkey's Metadata) embedded in it...
+//
+func SaveImageToFileAsPNGWithMetadata(filename string, image image.Image, metadata Metadata) {
+	CheckError(Save(filename, image, &SaveOptions{Format: FormatPNG, Metadata: metadata}))
+}
 
-	// jpeg.Encode(outfile, image, nil)
-	jpeg.Encode(outfile, image, &jpeg.Options{jpeg.DefaultQuality})
+//
+// SaveImageToFileAsJPGWithMetadata will save an image to the filesystem as a jpg, with the metadata (eg. from
+// monkey's Metadata) embedded in it...
+//
+func SaveImageToFileAsJPGWithMetadata(filename string, image image.Image, metadata Metadata) {
+	CheckError(Save(filename, image, &SaveOptions{Format: FormatJPEG, Metadata: metadata}))
 }
 
 //
 // SaveImageToFileAsGIF will save an image to the filesystem as a gif...
 //
 func SaveImageToFileAsGIF(filename string, image image.Image) {
-	outfile, err := os.Create(filename)
-	CheckError(err)
-	defer outfile.Close()
+	CheckError(Save(filename, image, &SaveOptions{Format: FormatGIF}))
+}
+
+//
+// SaveImageToFileAsGIFWithPalette will save an image to the filesystem as a gif, using a palette of (at most)
+// numColours colours picked by quantizer (eg. a monkey.Quantizer) rather than the default Plan 9 palette. The
+// image is drawn onto the palette with drawer; if it's nil, the gif package's default (Floyd-Steinberg
+// dithering) is used, and draw.Src just uses the nearest colour.
+//
+func SaveImageToFileAsGIFWithPalette(filename string, image image.Image, numColours int, quantizer draw.Quantizer, drawer draw.Drawer) {
+	CheckError(Save(filename, image, &SaveOptions{
+		Format:        FormatGIF,
+		GIFNumColours: numColours,
+		GIFQuantizer:  quantizer,
+		GIFDrawer:     drawer,
+	}))
+}
 
-	gif.Encode(outfile, image, nil)
+//
+// SaveImageToFileAsIndexedPNG will save an image to the filesystem as an indexed (paletted) png, with the palette
+// picked and the image drawn onto it the same way as SaveImageToFileAsGIFWithPalette...
+//
+func SaveImageToFileAsIndexedPNG(filename string, image image.Image, numColours int, quantizer draw.Quantizer, drawer draw.Drawer) {
+	CheckError(Save(filename, PalettedImage(image, numColours, quantizer, drawer), &SaveOptions{Format: FormatPNG}))
+}
+
+//
+// PalettedImage returns the image drawn onto a palette of (at most) numColours colours (1-256) picked by quantizer
+// (if drawer is nil, Floyd-Steinberg dithering is used, the same as the gif package)
+//
+func PalettedImage(img image.Image, numColours int, quantizer draw.Quantizer, drawer draw.Drawer) *image.Paletted {
+	if numColours < 1 || numColours > 256 {
+		numColours = 256
+	}
+
+	if drawer == nil {
+		drawer = draw.FloydSteinberg
+	}
+
+	bounds := img.Bounds()
+	palette := quantizer.Quantize(make(color.Palette, 0, numColours), img)
+	paletted := image.NewPaletted(bounds, palette)
+	drawer.Draw(paletted, bounds, img, bounds.Min)
+
+	return paletted
+}
+
+//
+// SaveGIFToFile will save a (possibly animated) gif to the filesystem, with all of it's frames and timings...
+//
+func SaveGIFToFile(filename string, g *gif.GIF) {
+	CheckError(writeFileAtomically(filename, func(w io.Writer) error {
+		return gif.EncodeAll(w, g)
+	}))
+}
+
+//
+// SaveImageToFileAsNetpbm will save an image to the filesystem in one of the Netpbm formats (the options say
+// which, and whether it's plain/ascii and/or 16 bit)...
+//
+func SaveImageToFileAsNetpbm(filename string, image image.Image, options *netpbm.Options) {
+	if options == nil {
+		options = &netpbm.Options{}
+	}
+
+	saveOptions := &SaveOptions{NetpbmASCII: options.ASCII, NetpbmSixteenBit: options.SixteenBit}
+	for format, netpbmFormat := range netpbmFormats {
+		if netpbmFormat == options.Format {
+			saveOptions.Format = format
+		}
+	}
+
+	CheckError(Save(filename, image, saveOptions))
+}
+
+//
+// SaveImageToFileAsPPM will save an image to the filesystem as a (binary, 8 bit) ppm...
+//
+func SaveImageToFileAsPPM(filename string, image image.Image) {
+	SaveImageToFileAsNetpbm(filename, image, &netpbm.Options{Format: netpbm.PPM})
+}
+
+//
+// SaveImageToFileAsPGM will save an image to the filesystem as a (binary, 8 bit) greyscale pgm...
+//
+func SaveImageToFileAsPGM(filename string, image image.Image) {
+	SaveImageToFileAsNetpbm(filename, image, &netpbm.Options{Format: netpbm.PGM})
+}
+
+//
+// SaveImageToFileAsPBM will save an image to the filesystem as a (binary) black and white pbm...
+//
+func SaveImageToFileAsPBM(filename string, image image.Image) {
+	SaveImageToFileAsNetpbm(filename, image, &netpbm.Options{Format: netpbm.PBM})
+}
+
+//
+// SaveImageToFileAsPAM will save an image to the filesystem as an (8 bit) pam, which keeps the alpha channel...
+//
+func SaveImageToFileAsPAM(filename string, image image.Image) {
+	SaveImageToFileAsNetpbm(filename, image, &netpbm.Options{Format: netpbm.PAM})
+}
+
+//
+// SaveImageToFileAsBMP will save an image to the filesystem as a bmp (24 bit, or 32 bit if it has any transparency)...
+//
+func SaveImageToFileAsBMP(filename string, image image.Image) {
+	CheckError(Save(filename, image, &SaveOptions{Format: FormatBMP}))
+}
+
+//
+// SaveImageToFileAsTGA will save an image to the filesystem as a (32 bit) tga, run length encoded if rle is true...
+//
+func SaveImageToFileAsTGA(filename string, image image.Image, rle bool) {
+	CheckError(Save(filename, image, &SaveOptions{Format: FormatTGA, TGARLE: rle}))
+}
+
+//
+// SaveImageToFileAsQOI will save an image to the filesystem as a qoi...
+//
+func SaveImageToFileAsQOI(filename string, image image.Image) {
+	CheckError(Save(filename, image, &SaveOptions{Format: FormatQOI}))
 }
@@ -0,0 +1,285 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"../formats/bmp"
+	"../formats/netpbm"
+	"../formats/qoi"
+	"../formats/tga"
+)
+
+//
+// Format is an image format that Save (and Encode) can write. The values are the names image.Decode reports, so
+// the format an image was loaded as can be handed straight back...
+//
+type Format string
+
+const (
+	FormatPNG  Format = "png"
+	FormatJPEG Format = "jpeg"
+	FormatGIF  Format = "gif"
+	FormatBMP  Format = "bmp"
+	FormatTGA  Format = "tga"
+	FormatQOI  Format = "qoi"
+	FormatPBM  Format = "pbm"
+	FormatPGM  Format = "pgm"
+	FormatPPM  Format = "ppm"
+	FormatPAM  Format = "pam"
+)
+
+//
+// formatExtensions maps (lower case) file extensions to the format they are saved as
+//
+var formatExtensions = map[string]Format{
+	".png":  FormatPNG,
+	".jpg":  FormatJPEG,
+	".jpeg": FormatJPEG,
+	".gif":  FormatGIF,
+	".bmp":  FormatBMP,
+	".tga":  FormatTGA,
+	".qoi":  FormatQOI,
+	".pbm":  FormatPBM,
+	".pgm":  FormatPGM,
+	".ppm":  FormatPPM,
+	".pnm":  FormatPPM,
+	".pam":  FormatPAM,
+}
+
+//
+// netpbmFormats maps our Netpbm formats to the netpbm package's
+//
+var netpbmFormats = map[Format]netpbm.Format{
+	FormatPBM: netpbm.PBM,
+	FormatPGM: netpbm.PGM,
+	FormatPPM: netpbm.PPM,
+	FormatPAM: netpbm.PAM,
+}
+
+//
+// ChromaSubsampling is how much of the colour (as opposed to brightness) information a jpeg keeps...
+//
+type ChromaSubsampling int
+
+const (
+	// Subsampling420 keeps one colour sample for every 2x2 block of pixels (what nearly every jpeg uses)
+	Subsampling420 ChromaSubsampling = iota
+
+	// Subsampling422 keeps one colour sample for every 2 pixels across
+	Subsampling422
+
+	// Subsampling444 keeps the colour of every pixel
+	Subsampling444
+)
+
+//
+// ErrUnsupportedSubsampling is returned when asked for a jpeg with anything but 4:2:0 subsampling; the standard
+// library's jpeg encoder can't do anything else
+//
+var ErrUnsupportedSubsampling = errors.New("util: the jpeg encoder only supports 4:2:0 chroma subsampling")
+
+//
+// SaveOptions are the settings for Save and Encode. The zero value (or a nil *SaveOptions) works out the format
+// from the filename and uses each encoder's defaults; any option that doesn't apply to the format is ignored.
+//
+type SaveOptions struct {
+	// Format overrides the format picked from the filename's extension
+	Format Format
+
+	// JPEGQuality is 1-100 (0 means jpeg.DefaultQuality)
+	JPEGQuality int
+
+	// JPEGSubsampling is the chroma subsampling to save jpegs with; anything but Subsampling420 (the zero value)
+	// makes Encode return ErrUnsupportedSubsampling
+	JPEGSubsampling ChromaSubsampling
+
+	// PNGCompression is how hard the png encoder tries to make the file smaller (the zero value is the default)
+	PNGCompression png.CompressionLevel
+
+	// GIFNumColours is how many colours (1-256) the gif's palette can have (0 means 256)
+	GIFNumColours int
+
+	// GIFQuantizer picks the gif's palette (eg. a monkey.Quantizer); nil uses the Plan 9 palette
+	GIFQuantizer draw.Quantizer
+
+	// GIFDrawer draws the image onto the gif's palette (eg. a monkey.Ditherer, or draw.Src for no dithering);
+	// nil uses Floyd-Steinberg dithering
+	GIFDrawer draw.Drawer
+
+	// TGARLE run length encodes tgas
+	TGARLE bool
+
+	// NetpbmASCII and NetpbmSixteenBit are passed on as the netpbm package's ASCII and SixteenBit options
+	NetpbmASCII      bool
+	NetpbmSixteenBit bool
+
+	// Metadata (eg. from monkey's Metadata) is embedded in pngs and jpegs; a jpeg keeps text under any keyword
+	// but "Comment" as a "Keyword: text" comment (see Metadata.Text)
+	Metadata Metadata
+}
+
+//
+// FormatFromFilename returns the format that goes with the filename's extension...
+//
+func FormatFromFilename(filename string) (Format, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if format, ok := formatExtensions[ext]; ok {
+		return format, nil
+	}
+
+	return "", fmt.Errorf("util: don't know what format to save %q as", filename)
+}
+
+//
+// Save will save an image to the filesystem in the format given by the options, or failing that by the filename's
+// extension. The image is written to a temporary file in the same directory, which is then renamed, so there is
+// never a half written file at filename (even if something goes wrong part way through).
+//
+func Save(filename string, img image.Image, options *SaveOptions) error {
+	format := Format("")
+	if options != nil {
+		format = options.Format
+	}
+
+	if format == "" {
+		var err error
+		if format, err = FormatFromFilename(filename); err != nil {
+			return err
+		}
+	}
+
+	return writeFileAtomically(filename, func(w io.Writer) error {
+		return Encode(w, img, format, options)
+	})
+}
+
+//
+// writeFileAtomically calls write to fill a temporary file in the same directory as filename, and then renames it
+// to filename; if anything goes wrong the temporary file is removed, and whatever was at filename is left alone.
+// The file ends up with the same permissions as the one it replaces, or (for a new file) whatever os.Create would
+// have given it
+//
+func writeFileAtomically(filename string, write func(w io.Writer) error) error {
+	tempfile, err := createTempFile(filename)
+	if err != nil {
+		return err
+	}
+
+	err = write(tempfile)
+	if info, statErr := os.Stat(filename); err == nil && statErr == nil {
+		err = tempfile.Chmod(info.Mode().Perm())
+	}
+	if err == nil {
+		err = tempfile.Sync()
+	}
+	if closeErr := tempfile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempfile.Name(), filename)
+	}
+
+	if err != nil {
+		os.Remove(tempfile.Name())
+	}
+
+	return err
+}
+
+//
+// createTempFile creates a new, empty file next to filename. Unlike ioutil.TempFile (which always uses 0600) it's
+// created with 0666 less the umask, the same as os.Create
+//
+func createTempFile(filename string) (*os.File, error) {
+	prefix := filepath.Join(filepath.Dir(filename), "."+filepath.Base(filename)+".")
+
+	for try := 0; ; try++ {
+		name := prefix + strconv.FormatUint(uint64(rand.Uint32()), 10) + ".tmp"
+
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && try < 100 {
+			continue
+		}
+
+		return file, err
+	}
+}
+
+//
+// Encode writes the image to w in the given format, using the options (which can be nil)...
+//
+func Encode(w io.Writer, img image.Image, format Format, options *SaveOptions) error {
+	if options == nil {
+		options = &SaveOptions{}
+	}
+
+	switch format {
+	case FormatPNG:
+		encoder := png.Encoder{CompressionLevel: options.PNGCompression}
+		if options.Metadata.IsEmpty() {
+			return encoder.Encode(w, img)
+		}
+
+		buffer := &bytes.Buffer{}
+		if err := encoder.Encode(buffer, img); err != nil {
+			return err
+		}
+
+		_, err := w.Write(EmbedMetadataInPNG(buffer.Bytes(), options.Metadata))
+		return err
+	case FormatJPEG:
+		if options.JPEGSubsampling != Subsampling420 {
+			return ErrUnsupportedSubsampling
+		}
+
+		quality := options.JPEGQuality
+		if quality == 0 {
+			quality = jpeg.DefaultQuality
+		}
+
+		if options.Metadata.IsEmpty() {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+		}
+
+		buffer := &bytes.Buffer{}
+		if err := jpeg.Encode(buffer, img, &jpeg.Options{Quality: quality}); err != nil {
+			return err
+		}
+
+		_, err := w.Write(EmbedMetadataInJPEG(buffer.Bytes(), options.Metadata))
+		return err
+	case FormatGIF:
+		return gif.Encode(w, img, &gif.Options{
+			NumColors: options.GIFNumColours,
+			Quantizer: options.GIFQuantizer,
+			Drawer:    options.GIFDrawer,
+		})
+	case FormatBMP:
+		return bmp.Encode(w, img)
+	case FormatTGA:
+		return tga.Encode(w, img, &tga.Options{RLE: options.TGARLE})
+	case FormatQOI:
+		return qoi.Encode(w, img)
+	case FormatPBM, FormatPGM, FormatPPM, FormatPAM:
+		return netpbm.Encode(w, img, &netpbm.Options{
+			Format:     netpbmFormats[format],
+			ASCII:      options.NetpbmASCII,
+			SixteenBit: options.NetpbmSixteenBit,
+		})
+	}
+
+	return fmt.Errorf("util: can't save images as %q", format)
+}
@@ -0,0 +1,83 @@
+package util
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeJPEGSubsampling(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+
+	for _, test := range []struct {
+		subsampling ChromaSubsampling
+		err         error
+	}{
+		{Subsampling420, nil},
+		{Subsampling422, ErrUnsupportedSubsampling},
+		{Subsampling444, ErrUnsupportedSubsampling},
+	} {
+		buffer := &bytes.Buffer{}
+		err := Encode(buffer, img, FormatJPEG, &SaveOptions{JPEGSubsampling: test.subsampling})
+		if err != test.err {
+			t.Errorf("subsampling %v: Encode returned %v, want %v", test.subsampling, err, test.err)
+		}
+		if err != nil && buffer.Len() != 0 {
+			t.Errorf("subsampling %v: Encode wrote %v bytes before failing", test.subsampling, buffer.Len())
+		}
+	}
+}
+
+func TestSavePermissions(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+
+	// a new file gets the same permissions os.Create would give it (0666 less the umask)
+	created, err := os.Create(filepath.Join(dir, "created.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created.Close()
+	want := fileMode(t, created.Name())
+
+	filename := filepath.Join(dir, "new.png")
+	if err := Save(filename, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := fileMode(t, filename); got != want {
+		t.Errorf("a new file was saved with mode %v, want %v", got, want)
+	}
+
+	// and saving over a file keeps it's permissions, whether they're wider or narrower than that
+	for _, mode := range []os.FileMode{0600, 0640, 0777} {
+		if err := os.Chmod(filename, mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := Save(filename, img, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := fileMode(t, filename); got != mode {
+			t.Errorf("saving over a file with mode %v changed it to %v", mode, got)
+		}
+	}
+
+	if files, _ := filepath.Glob(filepath.Join(dir, ".*.tmp")); len(files) != 0 {
+		t.Errorf("temporary files were left behind: %v", files)
+	}
+}
+
+//
+// fileMode returns the permission bits of the named file, failing the test if it can't
+//
+func fileMode(t *testing.T, filename string) os.FileMode {
+	t.Helper()
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return info.Mode().Perm()
+}